@@ -14,9 +14,44 @@ type AppSettings struct {
 	RateLimitWindow int // In seconds
 }
 
+// ProviderSettings configures the client of a single LLM provider. Empty values
+// fall back to the provider's environment variables.
+type ProviderSettings struct {
+	APIKey  string `mapstructure:"apiKey"`
+	BaseURL string `mapstructure:"baseURL"`
+}
+
+// ProviderRetryConfig configures how failed provider calls are retried and when a
+// provider/model is cut off by its circuit breaker.
+type ProviderRetryConfig struct {
+	MaxRetries       int `mapstructure:"maxRetries"`       // Retries of a transient failure, default 4
+	BaseDelay        int `mapstructure:"baseDelay"`        // In milliseconds, doubled on every retry, default 500
+	MaxDelay         int `mapstructure:"maxDelay"`         // In seconds, longest wait between retries, default 30
+	BreakerThreshold int `mapstructure:"breakerThreshold"` // Consecutive failures that open the breaker, default 5
+	BreakerCooldown  int `mapstructure:"breakerCooldown"`  // In seconds, how long the breaker stays open, default 30
+}
+
+type ProvidersConfig struct {
+	OpenAI           ProviderSettings    `mapstructure:"openAI"`
+	Anthropic        ProviderSettings    `mapstructure:"anthropic"`
+	OpenAICompatible ProviderSettings    `mapstructure:"openAICompatible"`
+	Retry            ProviderRetryConfig `mapstructure:"retry"`
+}
+
+// WorkersConfig configures the workers that execute queued conversations.
+type WorkersConfig struct {
+	Concurrency   int    `mapstructure:"concurrency"`   // Conversations run at once by this process
+	MaxAttempts   int    `mapstructure:"maxAttempts"`   // Runs of a conversation before it is failed
+	MaxConcurrent int    `mapstructure:"maxConcurrent"` // Conversations run at once across every process, 0 = unlimited
+	MaxPerSession int    `mapstructure:"maxPerSession"` // Conversations of one session run at once, 0 = unlimited
+	Ordering      string `mapstructure:"ordering"`      // "priority" (default) or "fifo"
+}
+
 // ConfigSettings contains the config.yml settings
 type Config struct {
-	App      AppSettings               `mapstructure:"app"`
-	Promtail promtail.Config           `mapstructure:"promtail"`
-	Postgres postgres.ConnectionConfig `mapstructure:"postgres"`
+	App       AppSettings               `mapstructure:"app"`
+	Promtail  promtail.Config           `mapstructure:"promtail"`
+	Postgres  postgres.ConnectionConfig `mapstructure:"postgres"`
+	Providers ProvidersConfig           `mapstructure:"providers"`
+	Workers   WorkersConfig             `mapstructure:"workers"`
 }
@@ -17,9 +17,10 @@ const DEFAULT_CONFIG_DIR = ".agent_composer/config"
 
 type Controller struct {
 	ctrl.BaseController
-	Config  Config
-	EnvVars EnvVars
-	DB      *relational.DB
+	Config    Config
+	EnvVars   EnvVars
+	DB        *relational.DB
+	ConfigDir string
 }
 
 func New() (*Controller, error) {
@@ -75,6 +76,7 @@ func New() (*Controller, error) {
 
 	controller.EnvVars = e
 	controller.Config = c
+	controller.ConfigDir = configDir
 
 	log.Info().Str("Env", controller.Environment).Msg("Starting Agent Composer")
 
@@ -9,6 +9,7 @@ import (
 	"github.com/vanclief/agent-composer/core/controller"
 	"github.com/vanclief/agent-composer/core/resources/agents"
 	"github.com/vanclief/agent-composer/core/resources/hooks"
+	"github.com/vanclief/agent-composer/core/resources/providers"
 	"github.com/vanclief/agent-composer/runtime"
 	"github.com/vanclief/compose/components/logger"
 	"github.com/vanclief/compose/components/scheduler"
@@ -18,11 +19,12 @@ const tickTime = 1 * time.Minute
 
 // Stack represents the core services required by any interface.
 type Stack struct {
-	Controller *controller.Controller
-	Scheduler  *scheduler.Scheduler
-	Runtime    *runtime.Runtime
-	AgentsAPI  *agents.API
-	HooksAPI   *hooks.API
+	Controller   *controller.Controller
+	Scheduler    *scheduler.Scheduler
+	Runtime      *runtime.Runtime
+	AgentsAPI    *agents.API
+	HooksAPI     *hooks.API
+	ProvidersAPI *providers.API
 }
 
 // New builds the application stack (controller, scheduler, runtime, APIs).
@@ -43,23 +45,65 @@ func NewStack(rootCtx context.Context) (*Stack, error) {
 		return nil, err
 	}
 
-	rt, err := runtime.New(rootCtx, ctrl, sch)
+	rt, err := runtime.New(ctrl)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recover the conversations orphaned by a previous process, then keep sweeping for
+	// runs whose lease lapsed
+	err = rt.RecoverConversations(rootCtx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to recover orphaned conversations")
+	}
+
+	err = sch.AddMany("conversations:recover", everyTick(), func(ctx context.Context) {
+		err := rt.RecoverConversations(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to recover orphaned conversations")
+		}
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	agentsAPI := agents.NewAPI(ctrl, rt)
 	hooksAPI := hooks.NewAPI(ctrl, rt)
+	providersAPI := providers.NewAPI(ctrl, rt)
 
 	return &Stack{
-		Controller: ctrl,
-		Scheduler:  sch,
-		Runtime:    rt,
-		AgentsAPI:  agentsAPI,
-		HooksAPI:   hooksAPI,
+		Controller:   ctrl,
+		Scheduler:    sch,
+		Runtime:      rt,
+		AgentsAPI:    agentsAPI,
+		HooksAPI:     hooksAPI,
+		ProvidersAPI: providersAPI,
 	}, nil
 }
 
+// everyTick returns every scheduler slot of the hour.
+func everyTick() []int {
+	step := int(tickTime / time.Minute)
+
+	slots := make([]int, 0, 60/step)
+	for minute := 0; minute < 60; minute += step {
+		slots = append(slots, minute)
+	}
+
+	return slots
+}
+
+// StartWorkers blocks while the conversation workers run until the context is canceled.
+func (s *Stack) StartWorkers(ctx context.Context) {
+	s.Runtime.RunWorker(ctx)
+}
+
+// StartEventRelay blocks while the conversation events are shared with the other
+// processes using the database until the context is canceled.
+func (s *Stack) StartEventRelay(ctx context.Context) {
+	s.Runtime.RelayEvents(ctx)
+}
+
 // StartScheduler blocks while the scheduler runs until the context is canceled.
 func (s *Stack) StartScheduler(ctx context.Context) {
 	s.Scheduler.Start(ctx)
@@ -0,0 +1,82 @@
+package conversations
+
+import (
+	"fmt"
+
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// Attachment is an image or file sent along a prompt, either by URL or inline as
+// base64 data.
+type Attachment struct {
+	Type      types.ContentPartType `json:"type"`
+	URL       string                `json:"url,omitempty"`
+	Data      string                `json:"data,omitempty"`
+	MediaType string                `json:"media_type,omitempty"`
+	Filename  string                `json:"filename,omitempty"`
+}
+
+func (a Attachment) toContentPart() types.ContentPart {
+	return types.ContentPart{
+		Type:      a.Type,
+		URL:       a.URL,
+		Data:      a.Data,
+		MediaType: a.MediaType,
+		Filename:  a.Filename,
+	}
+}
+
+// maxAttachments bounds the attachments of a single prompt.
+const maxAttachments = 20
+
+func validateAttachments(attachments []Attachment) error {
+	const op = "conversations.validateAttachments"
+
+	if len(attachments) > maxAttachments {
+		errMsg := fmt.Sprintf("a prompt can have at most %d attachments", maxAttachments)
+		return ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	for i, attachment := range attachments {
+		if attachment.Type == types.ContentPartText {
+			return ez.New(op, ez.EINVALID, "attachments must be images or files, send text in the prompt", nil)
+		}
+
+		err := attachment.toContentPart().Validate()
+		if err != nil {
+			errMsg := fmt.Sprintf("attachment %d is invalid: %s", i, ez.ErrorMessage(err))
+			return ez.New(op, ez.EINVALID, errMsg, err)
+		}
+	}
+
+	return nil
+}
+
+// contentParts converts the attachments of a prompt, rejecting images for models
+// the catalog knows can't see them.
+func contentParts(rt *runtime.Runtime, conversation *agent.Conversation, attachments []Attachment) ([]types.ContentPart, error) {
+	const op = "conversations.contentParts"
+
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+
+	parts := make([]types.ContentPart, 0, len(attachments))
+	hasImages := false
+
+	for _, attachment := range attachments {
+		parts = append(parts, attachment.toContentPart())
+		hasImages = hasImages || attachment.Type == types.ContentPartImage
+	}
+
+	model, ok := rt.Catalog().Lookup(conversation.Provider, conversation.Model)
+	if hasImages && ok && !model.Capabilities.Vision {
+		errMsg := fmt.Sprintf("model %s doesn't accept images", conversation.Model)
+		return nil, ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	return parts, nil
+}
@@ -0,0 +1,39 @@
+package conversations
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/ez"
+)
+
+type CancelRequest struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+}
+
+func (r CancelRequest) Validate() error {
+	const op = "CancelRequest.Validate"
+
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.ConversationID, validation.Required),
+	)
+	if err != nil {
+		return ez.New(op, ez.EINVALID, err.Error(), nil)
+	}
+
+	return nil
+}
+
+func (api *API) Cancel(ctx context.Context, requester interface{}, request *CancelRequest) (*agent.Conversation, error) {
+	const op = "conversations.API.Cancel"
+
+	// TODO: Permissions check
+	conversation, err := api.rt.CancelConversation(ctx, request.ConversationID)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return conversation, nil
+}
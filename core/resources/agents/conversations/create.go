@@ -2,11 +2,11 @@ package conversations
 
 import (
 	"context"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/google/uuid"
 	"github.com/vanclief/agent-composer/models/agent"
-	"github.com/vanclief/agent-composer/runtime"
 	"github.com/vanclief/ez"
 )
 
@@ -15,6 +15,15 @@ type CreateRequest struct {
 	Prompt                string    `json:"prompt"`
 	ParallelConversations int       `json:"parallel_conversations"`
 	SessionID             string    `json:"session_id,omitempty"`
+	Priority              int       `json:"priority"`
+	// Images and files sent along the prompt
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Budget overrides, the spec limits apply when unset
+	MaxCostCents   *int64 `json:"max_cost_cents,omitempty"`
+	MaxTotalTokens *int64 `json:"max_total_tokens,omitempty"`
+	MaxSteps       *int   `json:"max_steps,omitempty"`
+	// Tool calls of a step that may run at once, the spec limit applies when unset
+	MaxParallelToolCalls *int `json:"max_parallel_tool_calls,omitempty"`
 }
 
 func (r CreateRequest) Validate() error {
@@ -22,12 +31,36 @@ func (r CreateRequest) Validate() error {
 
 	err := validation.ValidateStruct(&r,
 		validation.Field(&r.AgentSpecID, validation.Required),
-		validation.Field(&r.Prompt, validation.Required),
 	)
 	if err != nil {
 		return ez.New(op, ez.EINVALID, err.Error(), nil)
 	}
 
+	if strings.TrimSpace(r.Prompt) == "" && len(r.Attachments) == 0 {
+		return ez.New(op, ez.EINVALID, "prompt is required unless attachments are sent", nil)
+	}
+
+	err = validateAttachments(r.Attachments)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if r.MaxCostCents != nil && *r.MaxCostCents < 0 {
+		return ez.New(op, ez.EINVALID, "max_cost_cents must be >= 0", nil)
+	}
+
+	if r.MaxTotalTokens != nil && *r.MaxTotalTokens < 0 {
+		return ez.New(op, ez.EINVALID, "max_total_tokens must be >= 0", nil)
+	}
+
+	if r.MaxSteps != nil && *r.MaxSteps < 0 {
+		return ez.New(op, ez.EINVALID, "max_steps must be >= 0", nil)
+	}
+
+	if r.MaxParallelToolCalls != nil && *r.MaxParallelToolCalls < 0 {
+		return ez.New(op, ez.EINVALID, "max_parallel_tool_calls must be >= 0", nil)
+	}
+
 	return nil
 }
 
@@ -54,25 +87,44 @@ func (api *API) Create(ctx context.Context, requester interface{}, request *Crea
 		request.ParallelConversations = 1
 	}
 
-	instances := make([]*runtime.ConversationInstance, 0, request.ParallelConversations)
+	// Step 2: Queue a job for each conversation, any worker can pick them up
+	response := &CreateResponse{
+		Conversations: make([]ConversationID, 0, request.ParallelConversations),
+	}
 
 	for i := 0; i < request.ParallelConversations; i++ {
-		instance, err := api.rt.NewConversationInstanceFromSpec(ctx, spec.ID, request.SessionID)
+		conversation, err := api.rt.NewConversationFromSpec(ctx, spec.ID, request.SessionID)
 		if err != nil {
 			return nil, ez.Wrap(op, err)
 		}
 
-		api.rt.RunConversationInstance(instance, request.Prompt)
+		if request.MaxCostCents != nil {
+			conversation.MaxCostCents = *request.MaxCostCents
+		}
 
-		instances = append(instances, instance)
-	}
+		if request.MaxTotalTokens != nil {
+			conversation.MaxTotalTokens = *request.MaxTotalTokens
+		}
 
-	response := &CreateResponse{
-		Conversations: make([]ConversationID, len(instances)),
-	}
+		if request.MaxSteps != nil {
+			conversation.MaxSteps = *request.MaxSteps
+		}
+
+		if request.MaxParallelToolCalls != nil {
+			conversation.MaxParallelToolCalls = *request.MaxParallelToolCalls
+		}
+
+		attachments, err := contentParts(api.rt, conversation, request.Attachments)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+
+		_, err = api.rt.EnqueueConversation(ctx, conversation, request.Prompt, attachments, request.Priority)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
 
-	for i, instance := range instances {
-		response.Conversations[i] = ConversationID{ID: instance.ID}
+		response.Conversations = append(response.Conversations, ConversationID{ID: conversation.ID})
 	}
 
 	return response, nil
@@ -0,0 +1,60 @@
+package conversations
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime"
+	"github.com/vanclief/ez"
+)
+
+type EventsRequest struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+}
+
+func (r EventsRequest) Validate() error {
+	const op = "EventsRequest.Validate"
+
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.ConversationID, validation.Required),
+	)
+	if err != nil {
+		return ez.New(op, ez.EINVALID, err.Error(), nil)
+	}
+
+	return nil
+}
+
+// EventStream is a live subscription to the events of a conversation. Close must be
+// called once the caller stops reading.
+type EventStream struct {
+	Conversation *agent.Conversation
+	Events       <-chan runtime.ConversationEvent
+	Close        func()
+}
+
+func (api *API) Events(ctx context.Context, requester interface{}, request *EventsRequest) (*EventStream, error) {
+	const op = "conversations.API.Events"
+
+	// Step 1: Subscribe before loading the conversation so no event is missed in between
+	events, unsubscribe := api.rt.SubscribeConversation(request.ConversationID)
+
+	// Step 2: Get the conversation
+	conversation, err := agent.GetConversationByID(ctx, api.db, request.ConversationID)
+	if err != nil {
+		unsubscribe()
+		return nil, ez.Wrap(op, err)
+	}
+
+	// TODO: Permissions check
+
+	stream := &EventStream{
+		Conversation: conversation,
+		Events:       events,
+		Close:        unsubscribe,
+	}
+
+	return stream, nil
+}
@@ -0,0 +1,246 @@
+package conversations
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/compose/primitives/enums"
+	"github.com/vanclief/ez"
+)
+
+// ExportFormat is the file format of a results export.
+type ExportFormat string
+
+const (
+	ExportFormatJSONL ExportFormat = "jsonl"
+	ExportFormatCSV   ExportFormat = "csv"
+)
+
+var exportFormatSet = enums.Set([]ExportFormat{
+	ExportFormatJSONL,
+	ExportFormatCSV,
+})
+
+func (f ExportFormat) Validate() error {
+	return enums.Validate(f, exportFormatSet)
+}
+
+func (f ExportFormat) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(f, exportFormatSet)
+}
+
+func (f *ExportFormat) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, f, exportFormatSet)
+}
+
+// ContentType returns the MIME type of the exported file.
+func (f ExportFormat) ContentType() string {
+	if f == ExportFormatCSV {
+		return "text/csv"
+	}
+
+	return "application/x-ndjson"
+}
+
+type ExportRequest struct {
+	SessionID string       `json:"session_id"`
+	Format    ExportFormat `json:"format"`
+}
+
+func (r ExportRequest) Validate() error {
+	const op = "ExportRequest.Validate"
+
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.SessionID, validation.Required),
+		validation.Field(&r.Format, validation.Required),
+	)
+	if err != nil {
+		return ez.New(op, ez.EINVALID, err.Error(), nil)
+	}
+
+	return nil
+}
+
+// exportRow is a line of a JSON Lines export.
+type exportRow struct {
+	ConversationID uuid.UUID                `json:"conversation_id"`
+	AgentSpecID    uuid.UUID                `json:"agent_spec_id"`
+	AgentName      string                   `json:"agent_name"`
+	Status         agent.ConversationStatus `json:"status"`
+	CreatedAt      time.Time                `json:"created_at"`
+	Result         any                      `json:"result"`
+}
+
+// Export returns the results of the conversations of a session that have one,
+// oldest first, as a JSON Lines or CSV file.
+func (api *API) Export(ctx context.Context, requester interface{}, request *ExportRequest) ([]byte, error) {
+	const op = "conversations.API.Export"
+
+	// TODO: Permissions check
+
+	items := []agent.Conversation{}
+
+	err := api.db.NewSelect().
+		Model(&items).
+		Column("id", "agent_spec_id", "agent_name", "status", "created_at", "result").
+		Where("conversation.session_id = ?", request.SessionID).
+		Where("conversation.result IS NOT NULL").
+		Order("conversation.created_at ASC", "conversation.id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	rows := make([]exportRow, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, exportRow{
+			ConversationID: item.ID,
+			AgentSpecID:    item.AgentSpecID,
+			AgentName:      item.AgentName,
+			Status:         item.Status,
+			CreatedAt:      item.CreatedAt,
+			Result:         item.Result,
+		})
+	}
+
+	var data []byte
+	if request.Format == ExportFormatCSV {
+		data, err = exportCSV(rows)
+	} else {
+		data, err = exportJSONL(rows)
+	}
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return data, nil
+}
+
+func exportJSONL(rows []exportRow) ([]byte, error) {
+	const op = "conversations.exportJSONL"
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+
+	for _, row := range rows {
+		err := encoder.Encode(row)
+		if err != nil {
+			return nil, ez.New(op, ez.EINTERNAL, "failed to encode result", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// exportCSV writes a column per top-level key of the results, prefixed with
+// "result.". Nested values are written as JSON, and results that are not objects
+// go to a single "result" column.
+func exportCSV(rows []exportRow) ([]byte, error) {
+	const op = "conversations.exportCSV"
+
+	keySet := map[string]bool{}
+	scalars := false
+
+	for _, row := range rows {
+		object, ok := row.Result.(map[string]any)
+		if !ok {
+			scalars = true
+			continue
+		}
+
+		for key := range object {
+			keySet[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	header := []string{"conversation_id", "agent_spec_id", "agent_name", "status", "created_at"}
+	if scalars {
+		header = append(header, "result")
+	}
+	for _, key := range keys {
+		header = append(header, "result."+key)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	err := writer.Write(header)
+	if err != nil {
+		return nil, ez.New(op, ez.EINTERNAL, "failed to write CSV header", err)
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.ConversationID.String(),
+			row.AgentSpecID.String(),
+			row.AgentName,
+			string(row.Status),
+			row.CreatedAt.Format(time.RFC3339),
+		}
+
+		object, isObject := row.Result.(map[string]any)
+
+		if scalars {
+			cell := ""
+			if !isObject {
+				cell = csvCell(row.Result)
+			}
+			record = append(record, cell)
+		}
+
+		for _, key := range keys {
+			record = append(record, csvCell(object[key]))
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return nil, ez.New(op, ez.EINTERNAL, "failed to write CSV row", err)
+		}
+	}
+
+	writer.Flush()
+
+	err = writer.Error()
+	if err != nil {
+		return nil, ez.New(op, ez.EINTERNAL, "failed to write CSV", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// csvCell formats a JSON value for a CSV cell, missing values are left empty.
+func csvCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
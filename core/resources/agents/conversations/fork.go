@@ -12,6 +12,7 @@ import (
 type ForkRequest struct {
 	ConversationID uuid.UUID `json:"conversation_id"`
 	Prompt         string    `json:"prompt"`
+	Priority       int       `json:"priority"`
 }
 
 func (r ForkRequest) Validate() error {
@@ -43,13 +44,11 @@ func (api *API) Fork(ctx context.Context, requester interface{}, request *ForkRe
 		return uuid.Nil, ez.Wrap(op, err)
 	}
 
-	// Step 3: Launch the fork
-	instance, err := api.rt.NewConversationInstance(ctx, fork.ID)
+	// Step 3: Queue the fork
+	_, err = api.rt.EnqueueConversation(ctx, fork, request.Prompt, nil, request.Priority)
 	if err != nil {
 		return uuid.Nil, ez.Wrap(op, err)
 	}
 
-	api.rt.RunConversationInstance(instance, request.Prompt)
-
 	return fork.ID, nil
 }
@@ -2,6 +2,9 @@ package conversations
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/vanclief/agent-composer/models/agent"
@@ -18,6 +21,47 @@ type ListRequest struct {
 	AgentSpecID uuid.UUID                 `json:"agent_spec_id,omitempty"`
 	Status      *agent.ConversationStatus `json:"status,omitempty"`
 	SessionID   string                    `json:"session_id,omitempty"`
+	Result      []ResultFilter            `json:"result,omitempty"`
+}
+
+// ResultFilter matches conversations whose structured result has Value at Path,
+// a dot separated list of keys such as "severity" or "finding.severity".
+type ResultFilter struct {
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+func (f ResultFilter) Validate() error {
+	const op = "conversations.ResultFilter.Validate"
+
+	for _, key := range strings.Split(f.Path, ".") {
+		if strings.TrimSpace(key) == "" {
+			errMsg := fmt.Sprintf("invalid result filter path %q", f.Path)
+			return ez.New(op, ez.EINVALID, errMsg, nil)
+		}
+	}
+
+	return nil
+}
+
+// containment returns the JSON document the result must contain to match, e.g.
+// {"finding":{"severity":"high"}}, which can use the GIN index on result.
+func (f ResultFilter) containment() (string, error) {
+	const op = "conversations.ResultFilter.containment"
+
+	keys := strings.Split(f.Path, ".")
+
+	document := f.Value
+	for i := len(keys) - 1; i >= 0; i-- {
+		document = map[string]any{keys[i]: document}
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return "", ez.New(op, ez.EINVALID, "result filter value is not valid JSON", err)
+	}
+
+	return string(data), nil
 }
 
 func (r *ListRequest) Validate() error {
@@ -28,6 +72,13 @@ func (r *ListRequest) Validate() error {
 		return ez.New(op, ez.EINVALID, err.Error(), nil)
 	}
 
+	for _, filter := range r.Result {
+		err = filter.Validate()
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
 	return nil
 }
 
@@ -65,6 +116,15 @@ func (api *API) List(ctx context.Context, requester interface{}, request *ListRe
 		selectQuery = selectQuery.Where("conversation.session_id = ?", request.SessionID)
 	}
 
+	for _, filter := range request.Result {
+		document, err := filter.containment()
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+
+		selectQuery = selectQuery.Where("conversation.result @> ?::jsonb", document)
+	}
+
 	selectQuery, err := pagination.ApplyCursorToQuery(selectQuery, &request.CursorRequest, model, pagination.DESC)
 	if err != nil {
 		return nil, ez.Wrap(op, err)
@@ -75,6 +135,16 @@ func (api *API) List(ctx context.Context, requester interface{}, request *ListRe
 		return nil, ez.Wrap(op, err)
 	}
 
+	page := make([]*agent.Conversation, 0, len(items))
+	for i := range items {
+		page = append(page, &items[i])
+	}
+
+	err = agent.LoadMessages(ctx, api.db, page...)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
 	resp, err := pagination.BuildCursorResponse(items, request.Limit)
 	if err != nil {
 		return nil, ez.Wrap(op, err)
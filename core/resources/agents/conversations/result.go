@@ -0,0 +1,61 @@
+package conversations
+
+import (
+	"context"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/ez"
+)
+
+type ResultRequest struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+}
+
+func (r ResultRequest) Validate() error {
+	const op = "ResultRequest.Validate"
+
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.ConversationID, validation.Required),
+	)
+	if err != nil {
+		return ez.New(op, ez.EINVALID, err.Error(), nil)
+	}
+
+	return nil
+}
+
+type ResultResponse struct {
+	ConversationID uuid.UUID                `json:"conversation_id"`
+	Status         agent.ConversationStatus `json:"status"`
+	Result         any                      `json:"result"`
+}
+
+func (api *API) Result(ctx context.Context, requester interface{}, request *ResultRequest) (*ResultResponse, error) {
+	const op = "conversations.API.Result"
+
+	conversation, err := agent.GetConversationByID(ctx, api.db, request.ConversationID)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	// TODO: Permissions check
+
+	if !conversation.StructuredOutput {
+		errMsg := fmt.Sprintf("conversation %s does not use structured output", conversation.ID)
+		return nil, ez.New(op, ez.ENOTFOUND, errMsg, nil)
+	}
+
+	if conversation.Result == nil {
+		errMsg := fmt.Sprintf("conversation %s has no result yet", conversation.ID)
+		return nil, ez.New(op, ez.ENOTFOUND, errMsg, nil)
+	}
+
+	return &ResultResponse{
+		ConversationID: conversation.ID,
+		Status:         conversation.Status,
+		Result:         conversation.Result,
+	}, nil
+}
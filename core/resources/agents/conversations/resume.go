@@ -2,6 +2,8 @@ package conversations
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/google/uuid"
@@ -12,6 +14,14 @@ import (
 type ResumeRequest struct {
 	ConversationID uuid.UUID `json:"conversation_id"`
 	Prompt         string    `json:"prompt"`
+	Priority       int       `json:"priority"`
+	// Images and files sent along the prompt
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Budget overrides, kept for the following runs. Cost and tokens count every run
+	// of the conversation, so a spent budget has to be raised to resume it.
+	MaxCostCents   *int64 `json:"max_cost_cents,omitempty"`
+	MaxTotalTokens *int64 `json:"max_total_tokens,omitempty"`
+	MaxSteps       *int   `json:"max_steps,omitempty"`
 }
 
 func (r ResumeRequest) Validate() error {
@@ -19,12 +29,32 @@ func (r ResumeRequest) Validate() error {
 
 	err := validation.ValidateStruct(&r,
 		validation.Field(&r.ConversationID, validation.Required),
-		validation.Field(&r.Prompt, validation.Required),
 	)
 	if err != nil {
 		return ez.New(op, ez.EINVALID, err.Error(), nil)
 	}
 
+	if strings.TrimSpace(r.Prompt) == "" && len(r.Attachments) == 0 {
+		return ez.New(op, ez.EINVALID, "prompt is required unless attachments are sent", nil)
+	}
+
+	err = validateAttachments(r.Attachments)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if r.MaxCostCents != nil && *r.MaxCostCents < 0 {
+		return ez.New(op, ez.EINVALID, "max_cost_cents must be >= 0", nil)
+	}
+
+	if r.MaxTotalTokens != nil && *r.MaxTotalTokens < 0 {
+		return ez.New(op, ez.EINVALID, "max_total_tokens must be >= 0", nil)
+	}
+
+	if r.MaxSteps != nil && *r.MaxSteps < 0 {
+		return ez.New(op, ez.EINVALID, "max_steps must be >= 0", nil)
+	}
+
 	return nil
 }
 
@@ -37,15 +67,37 @@ func (api *API) Resume(ctx context.Context, requester interface{}, request *Resu
 		return uuid.Nil, ez.Wrap(op, err)
 	}
 
-	// Step 2: Launch
-
 	// TODO: Permissions check
-	instance, err := api.rt.NewConversationInstance(ctx, conversation.ID)
+
+	// Step 2: Apply the budget overrides, a run that would halt right away is rejected
+	if request.MaxCostCents != nil {
+		conversation.MaxCostCents = *request.MaxCostCents
+	}
+
+	if request.MaxTotalTokens != nil {
+		conversation.MaxTotalTokens = *request.MaxTotalTokens
+	}
+
+	if request.MaxSteps != nil {
+		conversation.MaxSteps = *request.MaxSteps
+	}
+
+	reason := conversation.ExhaustedBudget()
+	if reason != "" {
+		errMsg := fmt.Sprintf("%s, raise max_cost_cents, max_total_tokens or max_steps to resume", reason)
+		return uuid.Nil, ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	// Step 3: Queue it again with the new prompt
+	attachments, err := contentParts(api.rt, conversation, request.Attachments)
 	if err != nil {
 		return uuid.Nil, ez.Wrap(op, err)
 	}
 
-	api.rt.RunConversationInstance(instance, request.Prompt)
+	_, err = api.rt.EnqueueConversation(ctx, conversation, request.Prompt, attachments, request.Priority)
+	if err != nil {
+		return uuid.Nil, ez.Wrap(op, err)
+	}
 
 	return conversation.ID, nil
 }
@@ -0,0 +1,88 @@
+package conversations
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/compose/drivers/databases/relational/postgres/pagination"
+	"github.com/vanclief/ez"
+)
+
+type StepsRequest struct {
+	pagination.CursorRequest
+	ConversationID uuid.UUID `json:"conversation_id"`
+	// Optional filters
+	Kind *agent.StepKind `json:"kind,omitempty"`
+}
+
+func (r *StepsRequest) Validate() error {
+	const op = "conversations.StepsRequest.Validate"
+
+	err := r.CursorRequest.Validate()
+	if err != nil {
+		return ez.New(op, ez.EINVALID, err.Error(), nil)
+	}
+
+	if r.ConversationID == uuid.Nil {
+		return ez.New(op, ez.EINVALID, "conversation_id is required", nil)
+	}
+
+	if r.Kind != nil {
+		err = r.Kind.Validate()
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
+	return nil
+}
+
+type StepsResponse struct {
+	pagination.CursorResponse
+	Steps []agent.ConversationStep `json:"steps"`
+}
+
+// Steps returns the LLM calls and tool executions recorded for a conversation,
+// oldest first.
+func (api *API) Steps(ctx context.Context, requester interface{}, request *StepsRequest) (*StepsResponse, error) {
+	const op = "conversations.API.Steps"
+
+	conversation, err := agent.GetConversationByID(ctx, api.db, request.ConversationID)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	// TODO: Permissions check
+
+	items := []agent.ConversationStep{}
+	model := agent.ConversationStep{}
+
+	selectQuery := api.db.NewSelect().
+		Model(&items).
+		Where("conversation_step.conversation_id = ?", conversation.ID)
+
+	if request.Kind != nil {
+		selectQuery = selectQuery.Where("conversation_step.kind = ?", *request.Kind)
+	}
+
+	selectQuery, err = pagination.ApplyCursorToQuery(selectQuery, &request.CursorRequest, model, pagination.ASC)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	err = selectQuery.Scan(ctx)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	resp, err := pagination.BuildCursorResponse(items, request.Limit)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return &StepsResponse{
+		Steps:          resp.GetItems().([]agent.ConversationStep),
+		CursorResponse: *resp,
+	}, nil
+}
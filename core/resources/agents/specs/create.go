@@ -11,19 +11,34 @@ import (
 )
 
 type CreateRequest struct {
-	Name                   string                       `json:"name"`
-	Provider               agent.LLMProvider            `json:"provider"`
-	Model                  string                       `json:"model"`
-	Instructions           string                       `json:"instructions"`
-	ReasoningEffort        runtimetypes.ReasoningEffort `json:"reasoning_effort"`
-	AutoCompact            bool                         `json:"auto_compact"`
-	CompactAtPercent       *int                         `json:"compact_at_percent"`
-	CompactionPrompt       string                       `json:"compaction_prompt"`
-	AllowedTools           []string                     `json:"allowed_tools"`
-	ShellAccess            *bool                        `json:"shell_access"`
-	WebSearch              *bool                        `json:"web_search"`
-	StructuredOutput       *bool                        `json:"structured_output"`
-	StructuredOutputSchema map[string]any               `json:"structured_output_schema"`
+	Name                       string                       `json:"name"`
+	Provider                   agent.LLMProvider            `json:"provider"`
+	Model                      string                       `json:"model"`
+	BaseURL                    string                       `json:"base_url"`
+	Instructions               string                       `json:"instructions"`
+	ReasoningEffort            runtimetypes.ReasoningEffort `json:"reasoning_effort"`
+	AutoCompact                bool                         `json:"auto_compact"`
+	CompactAtPercent           *int                         `json:"compact_at_percent"`
+	CompactionPrompt           string                       `json:"compaction_prompt"`
+	AllowedTools               []string                     `json:"allowed_tools"`
+	ShellAccess                *bool                        `json:"shell_access"`
+	WebSearch                  *bool                        `json:"web_search"`
+	StructuredOutput           *bool                        `json:"structured_output"`
+	StructuredOutputSchema     map[string]any               `json:"structured_output_schema"`
+	StructuredOutputRepairs    *int                         `json:"structured_output_repairs"`
+	FallbackModels             []agent.ModelRef             `json:"fallback_models"`
+	RecoveryPolicy             agent.RecoveryPolicy         `json:"recovery_policy"`
+	HistoryMode                agent.HistoryMode            `json:"history_mode"`
+	MaxConcurrentConversations int                          `json:"max_concurrent_conversations"`
+	MaxCostCents               int64                        `json:"max_cost_cents"`
+	MaxTotalTokens             int64                        `json:"max_total_tokens"`
+	MaxSteps                   int                          `json:"max_steps"`
+	MaxParallelToolCalls       int                          `json:"max_parallel_tool_calls"`
+	SequentialTools            []string                     `json:"sequential_tools"`
+	MaxConsecutiveToolFailures *int                         `json:"max_consecutive_tool_failures"`
+	LoopWindow                 *int                         `json:"loop_window"`
+	LoopThreshold              *int                         `json:"loop_threshold"`
+	LoopAction                 agent.LoopAction             `json:"loop_action"`
 }
 
 func (r CreateRequest) Validate() error {
@@ -40,18 +55,58 @@ func (r CreateRequest) Validate() error {
 		return ez.New(op, ez.EINVALID, err.Error(), nil)
 	}
 
+	if r.Provider == agent.LLMProviderOpenAICompatible && strings.TrimSpace(r.BaseURL) == "" {
+		return ez.New(op, ez.EINVALID, "base_url is required for open_ai_compatible providers", nil)
+	}
+
 	if r.CompactAtPercent != nil {
 		if *r.CompactAtPercent <= 0 || *r.CompactAtPercent > 100 {
 			return ez.New(op, ez.EINVALID, "compact_at_percent must be between 1 and 100", nil)
 		}
 	}
 
+	if r.MaxConcurrentConversations < 0 {
+		return ez.New(op, ez.EINVALID, "max_concurrent_conversations must be >= 0", nil)
+	}
+
+	if r.MaxCostCents < 0 {
+		return ez.New(op, ez.EINVALID, "max_cost_cents must be >= 0", nil)
+	}
+
+	if r.MaxTotalTokens < 0 {
+		return ez.New(op, ez.EINVALID, "max_total_tokens must be >= 0", nil)
+	}
+
+	if r.MaxSteps < 0 {
+		return ez.New(op, ez.EINVALID, "max_steps must be >= 0", nil)
+	}
+
+	if r.MaxParallelToolCalls < 0 {
+		return ez.New(op, ez.EINVALID, "max_parallel_tool_calls must be >= 0", nil)
+	}
+
+	if r.MaxConsecutiveToolFailures != nil && *r.MaxConsecutiveToolFailures < 0 {
+		return ez.New(op, ez.EINVALID, "max_consecutive_tool_failures must be >= 0", nil)
+	}
+
+	if r.LoopWindow != nil && *r.LoopWindow < 0 {
+		return ez.New(op, ez.EINVALID, "loop_window must be >= 0", nil)
+	}
+
+	if r.LoopThreshold != nil && (*r.LoopThreshold < 0 || *r.LoopThreshold == 1) {
+		return ez.New(op, ez.EINVALID, "loop_threshold must be 0 or at least 2", nil)
+	}
+
 	if r.StructuredOutput != nil && *r.StructuredOutput {
 		if len(r.StructuredOutputSchema) == 0 {
 			return ez.New(op, ez.EINVALID, "structured_output_schema is required when structured_output is true", nil)
 		}
 	}
 
+	if r.StructuredOutputRepairs != nil && *r.StructuredOutputRepairs < 0 {
+		return ez.New(op, ez.EINVALID, "structured_output_repairs must be >= 0", nil)
+	}
+
 	return nil
 }
 
@@ -60,16 +115,24 @@ func (api *API) Create(ctx context.Context, requester interface{}, request *Crea
 
 	// TODO: Permissions check
 
-	spec, err := agent.NewAgentSpec(request.Name, request.Provider, request.Model, request.Instructions, request.ReasoningEffort, 1)
+	spec, err := agent.NewAgentSpec(request.Name, request.Provider, request.Model, request.BaseURL, request.Instructions, request.ReasoningEffort, 1)
 	if err != nil {
 		return nil, ez.Wrap(op, err)
 	}
 
-	err = api.rt.ValidateModel(ctx, spec.Provider, spec.Model)
+	err = api.rt.ValidateModel(ctx, spec.Provider, spec.BaseURL, spec.Model)
 	if err != nil {
 		return nil, ez.Wrap(op, err)
 	}
 
+	for _, fallback := range request.FallbackModels {
+		err = api.rt.ValidateModel(ctx, fallback.Provider, fallback.BaseURL, fallback.Model)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+	}
+
+	spec.FallbackModels = request.FallbackModels
 	spec.AutoCompact = request.AutoCompact
 
 	if request.CompactAtPercent != nil {
@@ -99,6 +162,41 @@ func (api *API) Create(ctx context.Context, requester interface{}, request *Crea
 		spec.StructuredOutputSchema = request.StructuredOutputSchema
 	}
 
+	if request.StructuredOutputRepairs != nil {
+		spec.StructuredOutputRepairs = *request.StructuredOutputRepairs
+	}
+
+	if request.RecoveryPolicy != "" {
+		spec.RecoveryPolicy = request.RecoveryPolicy
+	}
+
+	if request.HistoryMode != "" {
+		spec.HistoryMode = request.HistoryMode
+	}
+
+	spec.MaxConcurrentConversations = request.MaxConcurrentConversations
+	spec.MaxCostCents = request.MaxCostCents
+	spec.MaxTotalTokens = request.MaxTotalTokens
+	spec.MaxSteps = request.MaxSteps
+	spec.MaxParallelToolCalls = request.MaxParallelToolCalls
+	spec.SequentialTools = request.SequentialTools
+
+	if request.MaxConsecutiveToolFailures != nil {
+		spec.MaxConsecutiveToolFailures = *request.MaxConsecutiveToolFailures
+	}
+
+	if request.LoopWindow != nil {
+		spec.LoopWindow = *request.LoopWindow
+	}
+
+	if request.LoopThreshold != nil {
+		spec.LoopThreshold = *request.LoopThreshold
+	}
+
+	if request.LoopAction != "" {
+		spec.LoopAction = request.LoopAction
+	}
+
 	err = spec.Insert(ctx, api.db)
 	if err != nil {
 		return nil, ez.Wrap(op, err)
@@ -11,19 +11,34 @@ import (
 )
 
 type UpdateRequest struct {
-	AgentSpecID            uuid.UUID          `json:"agent_spec_id"`
-	Provider               *agent.LLMProvider `json:"provider"`
-	Name                   *string            `json:"name"`
-	Model                  *string            `json:"model"`
-	Instructions           *string            `json:"instructions"`
-	AutoCompact            *bool              `json:"auto_compact"`
-	CompactAtPercent       *int               `json:"compact_at_percent"`
-	CompactionPrompt       *string            `json:"compaction_prompt"`
-	AllowedTools           *[]string          `json:"allowed_tools"`
-	ShellAccess            *bool              `json:"shell_access"`
-	WebSearch              *bool              `json:"web_search"`
-	StructuredOutput       *bool              `json:"structured_output"`
-	StructuredOutputSchema *map[string]any    `json:"structured_output_schema"`
+	AgentSpecID                uuid.UUID             `json:"agent_spec_id"`
+	Provider                   *agent.LLMProvider    `json:"provider"`
+	Name                       *string               `json:"name"`
+	Model                      *string               `json:"model"`
+	BaseURL                    *string               `json:"base_url"`
+	Instructions               *string               `json:"instructions"`
+	AutoCompact                *bool                 `json:"auto_compact"`
+	CompactAtPercent           *int                  `json:"compact_at_percent"`
+	CompactionPrompt           *string               `json:"compaction_prompt"`
+	AllowedTools               *[]string             `json:"allowed_tools"`
+	ShellAccess                *bool                 `json:"shell_access"`
+	WebSearch                  *bool                 `json:"web_search"`
+	StructuredOutput           *bool                 `json:"structured_output"`
+	StructuredOutputSchema     *map[string]any       `json:"structured_output_schema"`
+	StructuredOutputRepairs    *int                  `json:"structured_output_repairs"`
+	FallbackModels             *[]agent.ModelRef     `json:"fallback_models"`
+	RecoveryPolicy             *agent.RecoveryPolicy `json:"recovery_policy"`
+	HistoryMode                *agent.HistoryMode    `json:"history_mode"`
+	MaxConcurrentConversations *int                  `json:"max_concurrent_conversations"`
+	MaxCostCents               *int64                `json:"max_cost_cents"`
+	MaxTotalTokens             *int64                `json:"max_total_tokens"`
+	MaxSteps                   *int                  `json:"max_steps"`
+	MaxParallelToolCalls       *int                  `json:"max_parallel_tool_calls"`
+	SequentialTools            *[]string             `json:"sequential_tools"`
+	MaxConsecutiveToolFailures *int                  `json:"max_consecutive_tool_failures"`
+	LoopWindow                 *int                  `json:"loop_window"`
+	LoopThreshold              *int                  `json:"loop_threshold"`
+	LoopAction                 *agent.LoopAction     `json:"loop_action"`
 }
 
 func (r UpdateRequest) Validate() error {
@@ -42,12 +57,48 @@ func (r UpdateRequest) Validate() error {
 		}
 	}
 
+	if r.MaxConcurrentConversations != nil && *r.MaxConcurrentConversations < 0 {
+		return ez.New(op, ez.EINVALID, "max_concurrent_conversations must be >= 0", nil)
+	}
+
+	if r.MaxCostCents != nil && *r.MaxCostCents < 0 {
+		return ez.New(op, ez.EINVALID, "max_cost_cents must be >= 0", nil)
+	}
+
+	if r.MaxTotalTokens != nil && *r.MaxTotalTokens < 0 {
+		return ez.New(op, ez.EINVALID, "max_total_tokens must be >= 0", nil)
+	}
+
+	if r.MaxSteps != nil && *r.MaxSteps < 0 {
+		return ez.New(op, ez.EINVALID, "max_steps must be >= 0", nil)
+	}
+
+	if r.MaxParallelToolCalls != nil && *r.MaxParallelToolCalls < 0 {
+		return ez.New(op, ez.EINVALID, "max_parallel_tool_calls must be >= 0", nil)
+	}
+
+	if r.MaxConsecutiveToolFailures != nil && *r.MaxConsecutiveToolFailures < 0 {
+		return ez.New(op, ez.EINVALID, "max_consecutive_tool_failures must be >= 0", nil)
+	}
+
+	if r.LoopWindow != nil && *r.LoopWindow < 0 {
+		return ez.New(op, ez.EINVALID, "loop_window must be >= 0", nil)
+	}
+
+	if r.LoopThreshold != nil && (*r.LoopThreshold < 0 || *r.LoopThreshold == 1) {
+		return ez.New(op, ez.EINVALID, "loop_threshold must be 0 or at least 2", nil)
+	}
+
 	if r.StructuredOutput != nil && *r.StructuredOutput {
 		if r.StructuredOutputSchema == nil || len(*r.StructuredOutputSchema) == 0 {
 			return ez.New(op, ez.EINVALID, "structured_output_schema is required when structured_output is true", nil)
 		}
 	}
 
+	if r.StructuredOutputRepairs != nil && *r.StructuredOutputRepairs < 0 {
+		return ez.New(op, ez.EINVALID, "structured_output_repairs must be >= 0", nil)
+	}
+
 	return nil
 }
 
@@ -78,12 +129,30 @@ func (api *API) Update(ctx context.Context, requester interface{}, request *Upda
 
 	if request.Model != nil {
 		spec.Model = *request.Model
+		shouldInsert = true
+	}
+
+	if request.BaseURL != nil {
+		spec.BaseURL = strings.TrimSpace(*request.BaseURL)
+		shouldInsert = true
+	}
 
-		err = api.rt.ValidateModel(ctx, spec.Provider, spec.Model)
+	if request.Provider != nil || request.Model != nil || request.BaseURL != nil {
+		err = api.rt.ValidateModel(ctx, spec.Provider, spec.BaseURL, spec.Model)
 		if err != nil {
 			return nil, ez.Wrap(op, err)
 		}
+	}
+
+	if request.FallbackModels != nil {
+		for _, fallback := range *request.FallbackModels {
+			err = api.rt.ValidateModel(ctx, fallback.Provider, fallback.BaseURL, fallback.Model)
+			if err != nil {
+				return nil, ez.Wrap(op, err)
+			}
+		}
 
+		spec.FallbackModels = *request.FallbackModels
 		shouldInsert = true
 	}
 
@@ -135,6 +204,71 @@ func (api *API) Update(ctx context.Context, requester interface{}, request *Upda
 		shouldInsert = true
 	}
 
+	if request.StructuredOutputRepairs != nil {
+		spec.StructuredOutputRepairs = *request.StructuredOutputRepairs
+		shouldInsert = true
+	}
+
+	if request.RecoveryPolicy != nil {
+		spec.RecoveryPolicy = *request.RecoveryPolicy
+		shouldInsert = true
+	}
+
+	if request.HistoryMode != nil {
+		spec.HistoryMode = *request.HistoryMode
+		shouldInsert = true
+	}
+
+	if request.MaxConcurrentConversations != nil {
+		spec.MaxConcurrentConversations = *request.MaxConcurrentConversations
+		shouldInsert = true
+	}
+
+	if request.MaxCostCents != nil {
+		spec.MaxCostCents = *request.MaxCostCents
+		shouldInsert = true
+	}
+
+	if request.MaxTotalTokens != nil {
+		spec.MaxTotalTokens = *request.MaxTotalTokens
+		shouldInsert = true
+	}
+
+	if request.MaxSteps != nil {
+		spec.MaxSteps = *request.MaxSteps
+		shouldInsert = true
+	}
+
+	if request.MaxParallelToolCalls != nil {
+		spec.MaxParallelToolCalls = *request.MaxParallelToolCalls
+		shouldInsert = true
+	}
+
+	if request.SequentialTools != nil {
+		spec.SequentialTools = *request.SequentialTools
+		shouldInsert = true
+	}
+
+	if request.MaxConsecutiveToolFailures != nil {
+		spec.MaxConsecutiveToolFailures = *request.MaxConsecutiveToolFailures
+		shouldInsert = true
+	}
+
+	if request.LoopWindow != nil {
+		spec.LoopWindow = *request.LoopWindow
+		shouldInsert = true
+	}
+
+	if request.LoopThreshold != nil {
+		spec.LoopThreshold = *request.LoopThreshold
+		shouldInsert = true
+	}
+
+	if request.LoopAction != nil {
+		spec.LoopAction = *request.LoopAction
+		shouldInsert = true
+	}
+
 	if !shouldInsert {
 		return nil, ez.New(op, ez.EINVALID, "No fields to update", nil)
 	}
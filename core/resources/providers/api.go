@@ -0,0 +1,31 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/vanclief/agent-composer/core/controller"
+	"github.com/vanclief/agent-composer/runtime"
+	"github.com/vanclief/compose/drivers/databases/relational"
+)
+
+type API struct {
+	db            *relational.DB
+	rt            *runtime.Runtime
+	compatBaseURL string // OpenAI-compatible server from the config
+}
+
+func NewAPI(ctrl *controller.Controller, rt *runtime.Runtime) *API {
+	if ctrl == nil {
+		panic("Controller reference is nil")
+	} else if rt == nil {
+		panic("Runtime reference is nil")
+	}
+
+	api := &API{
+		db:            ctrl.DB,
+		rt:            rt,
+		compatBaseURL: strings.TrimSpace(ctrl.Config.Providers.OpenAICompatible.BaseURL),
+	}
+
+	return api
+}
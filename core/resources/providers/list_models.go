@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime/catalog"
+	"github.com/vanclief/ez"
+)
+
+type ListModelsRequest struct {
+	// Optional filters
+	Provider *agent.LLMProvider `json:"provider,omitempty"`
+	BaseURL  string             `json:"base_url,omitempty"` // Server to list for open_ai_compatible
+}
+
+func (r *ListModelsRequest) Validate() error {
+	const op = "providers.ListModelsRequest.Validate"
+
+	if r.Provider != nil {
+		err := r.Provider.Validate()
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
+	if r.BaseURL != "" {
+		parsed, err := url.Parse(r.BaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ez.New(op, ez.EINVALID, "base_url must be an absolute http(s) URL", err)
+		}
+	}
+
+	return nil
+}
+
+// Model is a model the provider credentials can use, with its catalog entry when
+// the catalog knows it.
+type Model struct {
+	ID      string         `json:"id"`
+	Catalog *catalog.Model `json:"catalog,omitempty"`
+}
+
+// ProviderModels lists the models of a provider. Providers that are not configured
+// or can't be reached are returned with available set to false and the reason.
+type ProviderModels struct {
+	Provider  agent.LLMProvider `json:"provider"`
+	BaseURL   string            `json:"base_url,omitempty"`
+	Available bool              `json:"available"`
+	Error     string            `json:"error,omitempty"`
+	Models    []Model           `json:"models"`
+}
+
+type ListModelsResponse struct {
+	Providers []ProviderModels `json:"providers"`
+}
+
+func (api *API) ListModels(ctx context.Context, requester interface{}, request *ListModelsRequest) (*ListModelsResponse, error) {
+	const op = "providers.API.ListModels"
+
+	err := request.Validate()
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	// TODO: permissions
+
+	providers := api.rt.Providers()
+	if request.Provider != nil {
+		providers = []agent.LLMProvider{*request.Provider}
+	}
+
+	response := &ListModelsResponse{Providers: make([]ProviderModels, 0, len(providers))}
+
+	// The configured key is sent to the server, so only servers already in use are listed
+	baseURL := strings.TrimSpace(request.BaseURL)
+	if baseURL != "" {
+		known, err := api.knownBaseURL(ctx, baseURL)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+
+		if !known {
+			return nil, ez.New(op, ez.EINVALID, "base_url must be the configured OpenAI-compatible server or one used by a spec", nil)
+		}
+	}
+
+	for _, provider := range providers {
+		// The base URL only points to OpenAI-compatible servers, the other providers
+		// use the one from the config
+		providerBaseURL := ""
+		if provider == agent.LLMProviderOpenAICompatible {
+			providerBaseURL = baseURL
+		}
+
+		entry := ProviderModels{Provider: provider, BaseURL: providerBaseURL, Models: []Model{}}
+
+		ids, err := api.rt.ListModels(ctx, provider, providerBaseURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ez.Wrap(op, err)
+			}
+
+			entry.Error = ez.ErrorMessage(err)
+			response.Providers = append(response.Providers, entry)
+			continue
+		}
+
+		entry.Available = true
+
+		for _, id := range ids {
+			model := Model{ID: id}
+
+			known, ok := api.rt.Catalog().Lookup(provider, id)
+			if ok {
+				model.Catalog = &known
+			}
+
+			entry.Models = append(entry.Models, model)
+		}
+
+		response.Providers = append(response.Providers, entry)
+	}
+
+	return response, nil
+}
+
+// knownBaseURL reports whether the OpenAI-compatible server at baseURL is the one
+// from the config or is used by a spec.
+func (api *API) knownBaseURL(ctx context.Context, baseURL string) (bool, error) {
+	const op = "providers.API.knownBaseURL"
+
+	if api.compatBaseURL != "" && baseURL == api.compatBaseURL {
+		return true, nil
+	}
+
+	known, err := agent.SpecUsesBaseURL(ctx, api.db, agent.LLMProviderOpenAICompatible, baseURL)
+	if err != nil {
+		return false, ez.Wrap(op, err)
+	}
+
+	return known, nil
+}
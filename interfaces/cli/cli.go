@@ -78,7 +78,7 @@ func runServer(ctx context.Context) error {
 		return err
 	}
 
-	app := restserver.New(stack.Controller, stack.AgentsAPI, stack.HooksAPI)
+	app := restserver.New(stack.Controller, stack.AgentsAPI, stack.HooksAPI, stack.ProvidersAPI)
 
 	group, gctx := errgroup.WithContext(ctx)
 
@@ -87,6 +87,16 @@ func runServer(ctx context.Context) error {
 		return nil
 	})
 
+	group.Go(func() error {
+		stack.StartWorkers(gctx)
+		return nil
+	})
+
+	group.Go(func() error {
+		stack.StartEventRelay(gctx)
+		return nil
+	})
+
 	group.Go(func() error {
 		return rest.Start(gctx, app, log.Logger)
 	})
@@ -191,6 +201,12 @@ func runTUI(ctx context.Context) error {
 		return nil
 	})
 
+	// Conversations run on the workers of agc rest, their live events come through the relay
+	group.Go(func() error {
+		stack.StartEventRelay(gctx)
+		return nil
+	})
+
 	group.Go(func() error {
 		err := tui.Start(gctx, stack)
 		if err != nil && !errors.Is(err, context.Canceled) {
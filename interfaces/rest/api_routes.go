@@ -23,9 +23,14 @@ func addAPIRoutes(e *echo.Echo, h *handler.Handler) {
 	conversations := agents.Group("/conversations")
 	conversations.GET("", h.ListConversations)
 	conversations.POST("", h.CreateConversation)
+	conversations.GET("/export", h.ExportConversationResults)
 	conversations.GET("/:id", h.GetConversation)
+	conversations.GET("/:id/result", h.GetConversationResult)
+	conversations.GET("/:id/steps", h.ListConversationSteps)
+	conversations.GET("/:id/events", h.StreamConversationEvents)
 	conversations.POST("/:id/fork", h.ForkConversation)
 	conversations.POST("/:id/resume", h.ResumeConversation)
+	conversations.POST("/:id/cancel", h.CancelConversation)
 	conversations.DELETE("/:id", h.DeleteConversation)
 
 	// Hooks
@@ -35,4 +40,7 @@ func addAPIRoutes(e *echo.Echo, h *handler.Handler) {
 	hooks.POST("", h.CreateHook)
 	hooks.PUT("/:id", h.UpdateHook)
 	hooks.DELETE("/:id", h.DeleteHook)
+
+	// Models
+	api.GET("/models", h.ListModels)
 }
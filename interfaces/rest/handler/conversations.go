@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/vanclief/agent-composer/core/resources/agents/conversations"
 	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime"
+	runtimetypes "github.com/vanclief/agent-composer/runtime/types"
 	"github.com/vanclief/compose/components/rest/requests"
 	"github.com/vanclief/compose/drivers/databases/relational/postgres/pagination"
 	"github.com/vanclief/ez"
@@ -55,9 +63,111 @@ func (h *Handler) ListConversations(c echo.Context) error {
 		requestBody.Status = &status
 	}
 
+	// Result filters are given as result.<path>=<value>, values that parse as JSON
+	// match as such so numbers and booleans can be filtered too
+	for name, values := range c.QueryParams() {
+		path, ok := strings.CutPrefix(name, "result.")
+		if !ok {
+			continue
+		}
+
+		for _, raw := range values {
+			value, err := runtimetypes.DecodeJSON(raw)
+			if err != nil {
+				value = raw
+			}
+
+			requestBody.Result = append(requestBody.Result, conversations.ResultFilter{Path: path, Value: value})
+		}
+	}
+
+	return h.JSONResponse(c, op, request, requestBody)
+}
+
+func (h *Handler) GetConversationResult(c echo.Context) error {
+	const op = "Handler.GetConversationResult"
+
+	request := requests.New(c.Request().Header, c.RealIP())
+
+	resourceID, err := h.GetParameterUUID(c, "id")
+	if err != nil {
+		return h.ManageError(c, op, request, err)
+	}
+
+	requestBody := &conversations.ResultRequest{
+		ConversationID: resourceID,
+	}
+
 	return h.JSONResponse(c, op, request, requestBody)
 }
 
+func (h *Handler) ListConversationSteps(c echo.Context) error {
+	const op = "Handler.ListConversationSteps"
+
+	request := requests.New(c.Request().Header, c.RealIP())
+
+	resourceID, err := h.GetParameterUUID(c, "id")
+	if err != nil {
+		return h.ManageError(c, op, request, err)
+	}
+
+	requestBody := &conversations.StepsRequest{
+		CursorRequest: pagination.CursorRequest{
+			Limit:  h.GetListLimit(c, 50),
+			Cursor: c.QueryParam("cursor"),
+		},
+		ConversationID: resourceID,
+	}
+
+	kindStr := c.QueryParam("kind")
+	if kindStr != "" {
+		kind := agent.StepKind(kindStr)
+		if err := kind.Validate(); err != nil {
+			return h.ManageError(c, op, request, ez.New(op, ez.EINVALID, "invalid kind", err))
+		}
+		requestBody.Kind = &kind
+	}
+
+	return h.JSONResponse(c, op, request, requestBody)
+}
+
+func (h *Handler) ExportConversationResults(c echo.Context) error {
+	const op = "Handler.ExportConversationResults"
+
+	request := requests.New(c.Request().Header, c.RealIP())
+
+	requestBody := &conversations.ExportRequest{
+		SessionID: c.QueryParam("session_id"),
+		Format:    conversations.ExportFormatJSONL,
+	}
+
+	formatParam := c.QueryParam("format")
+	if formatParam != "" {
+		format := conversations.ExportFormat(formatParam)
+		if err := format.Validate(); err != nil {
+			return h.ManageError(c, op, request, ez.New(op, ez.EINVALID, "invalid format", err))
+		}
+		requestBody.Format = format
+	}
+
+	request.SetBody(requestBody)
+
+	response, err := h.server.HandleRequest(request)
+	if err != nil {
+		return h.ManageError(c, op, request, err)
+	}
+
+	data, ok := response.([]byte)
+	if !ok {
+		return h.ManageError(c, op, request, ez.New(op, ez.EINTERNAL, "HandleRequest response is not a byte slice", nil))
+	}
+
+	filename := fmt.Sprintf("results.%s", requestBody.Format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	return c.Blob(http.StatusOK, requestBody.Format.ContentType(), data)
+}
+
 func (h *Handler) GetConversation(c echo.Context) error {
 	const op = "Handler.GetConversation"
 
@@ -135,3 +245,119 @@ func (h *Handler) ResumeConversation(c echo.Context) error {
 
 	return h.BindedJSONResponse(c, op, request, requestBody)
 }
+
+func (h *Handler) CancelConversation(c echo.Context) error {
+	const op = "Handler.CancelConversation"
+
+	request := requests.New(c.Request().Header, c.RealIP())
+
+	resourceID, err := h.GetParameterUUID(c, "id")
+	if err != nil {
+		return h.ManageError(c, op, request, err)
+	}
+
+	requestBody := &conversations.CancelRequest{
+		ConversationID: resourceID,
+	}
+
+	return h.JSONResponse(c, op, request, requestBody)
+}
+
+// sseKeepAlive is how often a comment is written so proxies don't drop idle streams.
+const sseKeepAlive = 15 * time.Second
+
+func (h *Handler) StreamConversationEvents(c echo.Context) error {
+	const op = "Handler.StreamConversationEvents"
+
+	request := requests.New(c.Request().Header, c.RealIP())
+
+	resourceID, err := h.GetParameterUUID(c, "id")
+	if err != nil {
+		return h.ManageError(c, op, request, err)
+	}
+
+	requestBody := &conversations.EventsRequest{
+		ConversationID: resourceID,
+	}
+
+	request.SetBody(requestBody)
+
+	response, err := h.server.HandleRequest(request)
+	if err != nil {
+		return h.ManageError(c, op, request, err)
+	}
+
+	stream, ok := response.(*conversations.EventStream)
+	if !ok {
+		return h.ManageError(c, op, request, ez.New(op, ez.EINTERNAL, "HandleRequest response is not an event stream", nil))
+	}
+	defer stream.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	// Start with the current status so clients know where the conversation stands
+	current := runtime.ConversationEvent{
+		ConversationID: stream.Conversation.ID,
+		Type:           runtime.ConversationEventStatus,
+		Status:         stream.Conversation.Status,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	err = writeServerSentEvent(res, current)
+	if err != nil || current.Status.IsTerminal() {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(res, ": keep-alive\n\n")
+			if err != nil {
+				return nil
+			}
+			res.Flush()
+
+		case event, ok := <-stream.Events:
+			if !ok {
+				return nil
+			}
+
+			err = writeServerSentEvent(res, event)
+			if err != nil {
+				return nil
+			}
+
+			// The stream ends with the conversation
+			if event.Type == runtime.ConversationEventStatus && event.Status.IsTerminal() {
+				return nil
+			}
+		}
+	}
+}
+
+func writeServerSentEvent(res *echo.Response, event runtime.ConversationEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data)
+	if err != nil {
+		return err
+	}
+
+	res.Flush()
+
+	return nil
+}
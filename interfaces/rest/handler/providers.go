@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/vanclief/agent-composer/core/resources/providers"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/compose/components/rest/requests"
+)
+
+// GET /models
+func (h *Handler) ListModels(c echo.Context) error {
+	const op = "Handler.ListModels"
+
+	req := requests.New(c.Request().Header, c.RealIP())
+
+	body := &providers.ListModelsRequest{
+		BaseURL: strings.TrimSpace(c.QueryParam("base_url")),
+	}
+
+	// Optional filters
+	if v := strings.TrimSpace(c.QueryParam("provider")); v != "" {
+		provider := agent.LLMProvider(v)
+		body.Provider = &provider
+	}
+
+	return h.JSONResponse(c, op, req, body)
+}
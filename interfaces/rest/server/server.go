@@ -10,6 +10,7 @@ import (
 	"github.com/vanclief/agent-composer/core/resources/agents/conversations"
 	"github.com/vanclief/agent-composer/core/resources/agents/specs"
 	"github.com/vanclief/agent-composer/core/resources/hooks"
+	"github.com/vanclief/agent-composer/core/resources/providers"
 	"github.com/vanclief/agent-composer/models/user"
 	"github.com/vanclief/compose/components/ratelimit"
 	"github.com/vanclief/compose/components/rest/requests"
@@ -17,20 +18,22 @@ import (
 )
 
 type Server struct {
-	Ctrl        *controller.Controller
-	RateLimiter *ratelimit.WindowCounter
-	AgentsAPI   *agents.API
-	HooksAPI    *hooks.API
+	Ctrl         *controller.Controller
+	RateLimiter  *ratelimit.WindowCounter
+	AgentsAPI    *agents.API
+	HooksAPI     *hooks.API
+	ProvidersAPI *providers.API
 }
 
-func New(ctrl *controller.Controller, agentsAPI *agents.API, hooksAPI *hooks.API) *Server {
+func New(ctrl *controller.Controller, agentsAPI *agents.API, hooksAPI *hooks.API, providersAPI *providers.API) *Server {
 	limiter := ratelimit.NewWindowCounter(ctrl.Config.App.RateLimitWindow, ctrl.Config.App.RateLimit)
 
 	return &Server{
-		Ctrl:        ctrl,
-		RateLimiter: limiter,
-		AgentsAPI:   agentsAPI,
-		HooksAPI:    hooksAPI,
+		Ctrl:         ctrl,
+		RateLimiter:  limiter,
+		AgentsAPI:    agentsAPI,
+		HooksAPI:     hooksAPI,
+		ProvidersAPI: providersAPI,
 	}
 }
 
@@ -79,8 +82,18 @@ func (s *Server) handleRequest(request requests.Request) (interface{}, error) {
 		return s.AgentsAPI.Conversations.Fork(request.GetContext(), nil, body)
 	case *conversations.ResumeRequest:
 		return s.AgentsAPI.Conversations.Resume(request.GetContext(), nil, body)
+	case *conversations.CancelRequest:
+		return s.AgentsAPI.Conversations.Cancel(request.GetContext(), nil, body)
 	case *conversations.DeleteRequest:
 		return s.AgentsAPI.Conversations.Delete(request.GetContext(), nil, body)
+	case *conversations.EventsRequest:
+		return s.AgentsAPI.Conversations.Events(request.GetContext(), nil, body)
+	case *conversations.ResultRequest:
+		return s.AgentsAPI.Conversations.Result(request.GetContext(), nil, body)
+	case *conversations.StepsRequest:
+		return s.AgentsAPI.Conversations.Steps(request.GetContext(), nil, body)
+	case *conversations.ExportRequest:
+		return s.AgentsAPI.Conversations.Export(request.GetContext(), nil, body)
 
 	case *hooks.ListRequest:
 		return s.HooksAPI.List(request.GetContext(), nil, body)
@@ -93,6 +106,9 @@ func (s *Server) handleRequest(request requests.Request) (interface{}, error) {
 	case *hooks.DeleteRequest:
 		return s.HooksAPI.Delete(request.GetContext(), nil, body)
 
+	case *providers.ListModelsRequest:
+		return s.ProvidersAPI.ListModels(request.GetContext(), nil, body)
+
 	default:
 		return nil, ez.New("rest.Server.handleRequest", ez.EINVALID, "Unsupported request type", nil)
 	}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/vanclief/agent-composer/mcp/shell"
 	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime"
 	runtimetypes "github.com/vanclief/agent-composer/runtime/types"
 )
 
@@ -23,6 +24,29 @@ type detailView struct {
 	width            int
 	height           int
 	messagesViewport viewport.Model
+	live             liveOutput
+	follow           bool
+}
+
+// liveOutput holds the output streamed for the step currently running.
+type liveOutput struct {
+	step      int
+	reasoning strings.Builder
+	text      strings.Builder
+	toolName  string
+	toolArgs  strings.Builder
+}
+
+func (l *liveOutput) Reset(step int) {
+	l.step = step
+	l.reasoning.Reset()
+	l.text.Reset()
+	l.toolName = ""
+	l.toolArgs.Reset()
+}
+
+func (l *liveOutput) Empty() bool {
+	return l.reasoning.Len() == 0 && l.text.Len() == 0 && l.toolArgs.Len() == 0
 }
 
 func newDetailView() detailView {
@@ -64,7 +88,7 @@ func (v *detailView) renderContent() string {
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString(statusStyle.Render("esc/q back   r refresh detail"))
+	b.WriteString(statusStyle.Render("esc/q back   r refresh detail   c cancel run"))
 	return b.String()
 }
 
@@ -121,14 +145,43 @@ func (v *detailView) Reset() {
 	v.pendingID = uuid.UUID{}
 	v.messagesViewport.SetYOffset(0)
 	v.messagesViewport.SetContent("")
+	v.live.Reset(0)
+	v.follow = false
 }
 
 func (v *detailView) HandleMsg(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
 	v.messagesViewport, cmd = v.messagesViewport.Update(msg)
+	v.follow = v.messagesViewport.AtBottom()
 	return cmd
 }
 
+// AppendEvent adds a streamed delta to the live output. It reports whether the
+// event started a new step, in which case the persisted messages are stale.
+func (v *detailView) AppendEvent(event runtime.ConversationEvent) bool {
+	newStep := event.Step != v.live.step
+	if newStep {
+		v.live.Reset(event.Step)
+	}
+
+	v.follow = v.follow || v.messagesViewport.AtBottom()
+
+	switch event.Type {
+	case runtime.ConversationEventReasoningDelta:
+		v.live.reasoning.WriteString(event.Delta)
+	case runtime.ConversationEventTextDelta:
+		v.live.text.WriteString(event.Delta)
+	case runtime.ConversationEventToolCallDelta:
+		if event.ToolName != v.live.toolName {
+			v.live.toolName = event.ToolName
+			v.live.toolArgs.Reset()
+		}
+		v.live.toolArgs.WriteString(event.Delta)
+	}
+
+	return newStep
+}
+
 func (v *detailView) viewportHeight() int {
 	const chromePadding = 6
 	minHeight := 5
@@ -143,6 +196,7 @@ func (v *detailView) viewportHeight() int {
 }
 
 func (v *detailView) resetViewportPosition() {
+	v.follow = false
 	v.messagesViewport.GotoTop()
 }
 
@@ -157,7 +211,40 @@ func (v *detailView) prepareMessagesViewport(contentWidth int, content string) {
 	if v.messagesViewport.Height != height {
 		v.messagesViewport.Height = height
 	}
+	if !v.live.Empty() {
+		content += "\n\n" + renderLiveOutput(&v.live, contentWidth)
+	}
 	v.messagesViewport.SetContent(content)
+	if v.follow {
+		v.messagesViewport.GotoBottom()
+	}
+}
+
+func renderLiveOutput(live *liveOutput, width int) string {
+	headerStyle := valueStyle.Copy().Bold(true).MaxWidth(width)
+	contentStyle := bodyStyle.Copy().MaxWidth(width)
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("[Assistant · streaming]"))
+
+	if reasoning := strings.TrimSpace(live.reasoning.String()); reasoning != "" {
+		b.WriteString("\n")
+		b.WriteString(statusStyle.Render(wrapText(reasoning, width)))
+	}
+
+	if live.toolArgs.Len() > 0 {
+		b.WriteString("\n")
+		b.WriteString(valueStyle.Bold(true).Render("Calling " + live.toolName))
+		b.WriteString("\n")
+		b.WriteString(contentStyle.Render(wrapText(live.toolArgs.String(), width)))
+	}
+
+	if text := strings.TrimSpace(live.text.String()); text != "" {
+		b.WriteString("\n")
+		b.WriteString(contentStyle.Render(wrapText(text, width)))
+	}
+
+	return b.String()
 }
 
 func renderConversationMessages(conv *agent.Conversation, width int) string {
@@ -214,6 +301,7 @@ func renderConversationSidebar(conv *agent.Conversation, width int) string {
 
 	appendField("Name", conv.AgentName)
 	appendField("Status", string(conv.Status))
+	appendField("Status reason", conv.StatusReason)
 	appendField("Provider", string(conv.Provider))
 	appendField("Model", conv.Model)
 	appendField("Reasoning", string(conv.ReasoningEffort))
@@ -224,12 +312,39 @@ func renderConversationSidebar(conv *agent.Conversation, width int) string {
 }
 
 func formatMessageContent(message runtimetypes.Message, width int) (string, bool) {
+	if message.Thinking != nil {
+		thinking := strings.TrimSpace(message.Thinking.Text)
+		if thinking == "" {
+			thinking = "<redacted reasoning>"
+		}
+		return statusStyle.Render(wrapText(thinking, width)), true
+	}
+	if message.ReasoningSummary != nil {
+		summary := "Reasoning summary:\n" + strings.TrimSpace(message.ReasoningSummary.Text)
+		return statusStyle.Render(wrapText(summary, width)), true
+	}
 	if message.Role == runtimetypes.MessageRoleTool {
 		if formatted, ok := formatToolMessageContent(message, width); ok {
 			return formatted, true
 		}
 	}
-	return wrapText(strings.TrimSpace(message.Content), width), false
+	content := strings.TrimSpace(message.Content)
+	for _, part := range message.Parts {
+		if part.Type != runtimetypes.ContentPartText {
+			content += "\n" + part.Describe()
+		}
+	}
+	if len(message.Citations) > 0 {
+		content += "\n\nSources:"
+		for idx, citation := range message.Citations {
+			source := citation.URL
+			if title := strings.TrimSpace(citation.Title); title != "" {
+				source = title + " - " + citation.URL
+			}
+			content += fmt.Sprintf("\n[%d] %s", idx+1, source)
+		}
+	}
+	return wrapText(strings.TrimSpace(content), width), false
 }
 
 func formatToolMessageContent(message runtimetypes.Message, width int) (string, bool) {
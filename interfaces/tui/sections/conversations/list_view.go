@@ -129,7 +129,7 @@ func (v *listView) UpdateRows(items []agent.Conversation) {
 	v.items = items
 	rows := make([]table.Row, len(items))
 	for i, conv := range items {
-		cost := fmt.Sprintf("$%.2f", float64(conv.Cost)/100)
+		cost := fmt.Sprintf("$%.2f", conv.Cost/100)
 		rows[i] = table.Row{conv.AgentName, string(conv.Status), string(conv.Provider), conv.Model, cost, conv.ID.String()}
 	}
 	v.table.SetRows(rows)
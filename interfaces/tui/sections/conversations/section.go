@@ -11,6 +11,7 @@ import (
 	"github.com/vanclief/agent-composer/core"
 	"github.com/vanclief/agent-composer/core/resources/agents/conversations"
 	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime"
 )
 
 var apiTimeout = 10 * time.Second
@@ -32,6 +33,11 @@ type Section struct {
 
 	list   listView
 	detail detailView
+
+	// Live events of the conversation open in the detail view
+	events      <-chan runtime.ConversationEvent
+	eventsID    uuid.UUID
+	unsubscribe func()
 }
 
 // New creates a conversations section.
@@ -70,7 +76,9 @@ func (s *Section) Update(msg tea.Msg) tea.Cmd {
 	case conversationListLoadedMsg:
 		s.handleConversationListLoaded(message)
 	case conversationDetailLoadedMsg:
-		s.handleConversationDetailLoaded(message)
+		return s.handleConversationDetailLoaded(message)
+	case conversationEventMsg:
+		return s.handleConversationEvent(message)
 	}
 	return nil
 }
@@ -88,7 +96,7 @@ func (s *Section) View() string {
 // ShortHelp implements sections.Section.
 func (s *Section) ShortHelp() string {
 	if s.mode == viewModeDetail {
-		return "esc/q back   r reload detail"
+		return "esc/q back   r reload detail   c cancel run"
 	}
 	return "enter open conversation   n/p pagination   r refresh"
 }
@@ -125,6 +133,8 @@ func (s *Section) handleDetailKeys(msg tea.KeyMsg) tea.Cmd {
 		return nil
 	case "r":
 		return s.reloadDetail()
+	case "c":
+		return s.cancelConversation()
 	}
 	return s.detail.HandleMsg(msg)
 }
@@ -151,6 +161,30 @@ func (s *Section) reloadDetail() tea.Cmd {
 	return s.showConversationDetail(id, false)
 }
 
+func (s *Section) cancelConversation() tea.Cmd {
+	conv := s.detail.conversation
+	if conv == nil || conv.Status.IsTerminal() {
+		return nil
+	}
+
+	if s.stack == nil || s.stack.AgentsAPI == nil || s.stack.AgentsAPI.Conversations == nil {
+		s.detail.err = fmt.Errorf("conversations API unavailable")
+		return nil
+	}
+
+	id := conv.ID
+	req := conversations.CancelRequest{ConversationID: id}
+
+	// The response carries the persisted conversation, so it's handled like a reload
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(s.ctx, apiTimeout)
+		defer cancel()
+
+		resp, err := s.stack.AgentsAPI.Conversations.Cancel(ctx, nil, &req)
+		return conversationDetailLoadedMsg{id: id, conversation: resp, err: err}
+	}
+}
+
 func (s *Section) showConversationDetail(id uuid.UUID, switchView bool) tea.Cmd {
 	if switchView {
 		s.mode = viewModeDetail
@@ -165,6 +199,7 @@ func (s *Section) showConversationDetail(id uuid.UUID, switchView bool) tea.Cmd
 }
 
 func (s *Section) returnToList() {
+	s.stopEvents()
 	s.detail.Reset()
 	s.mode = viewModeList
 }
@@ -267,20 +302,89 @@ func (s *Section) handleConversationListLoaded(msg conversationListLoadedMsg) {
 	}
 }
 
-func (s *Section) handleConversationDetailLoaded(msg conversationDetailLoadedMsg) {
+func (s *Section) handleConversationDetailLoaded(msg conversationDetailLoadedMsg) tea.Cmd {
 	if msg.id != s.detail.pendingID {
-		return
+		return nil
 	}
 
 	s.detail.loading = false
 	if msg.err != nil {
 		s.detail.err = msg.err
-		return
+		return nil
 	}
 
+	// Reloads triggered by live events keep following the output
+	following := s.events != nil && s.eventsID == msg.id && s.detail.follow
+
 	s.detail.err = nil
 	s.detail.conversation = msg.conversation
-	s.detail.resetViewportPosition()
+	if !following {
+		s.detail.resetViewportPosition()
+	}
+
+	if msg.conversation == nil || msg.conversation.Status.IsTerminal() {
+		s.stopEvents()
+		s.detail.live.Reset(0)
+		return nil
+	}
+
+	return s.watchEvents(msg.id)
+}
+
+// watchEvents subscribes to the live events of a running conversation.
+func (s *Section) watchEvents(id uuid.UUID) tea.Cmd {
+	if s.stack == nil || s.stack.Runtime == nil {
+		return nil
+	}
+
+	if s.events != nil && s.eventsID == id {
+		return nil
+	}
+
+	s.stopEvents()
+
+	s.events, s.unsubscribe = s.stack.Runtime.SubscribeConversation(id)
+	s.eventsID = id
+
+	return waitForConversationEvent(id, s.events)
+}
+
+func (s *Section) stopEvents() {
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+
+	s.events = nil
+	s.eventsID = uuid.UUID{}
+	s.unsubscribe = nil
+}
+
+func (s *Section) handleConversationEvent(msg conversationEventMsg) tea.Cmd {
+	if msg.closed || msg.id != s.eventsID || s.events == nil {
+		return nil
+	}
+
+	wait := waitForConversationEvent(s.eventsID, s.events)
+
+	// Status changes and new steps mean new persisted messages, reload them in
+	// place without the loading placeholder
+	if msg.event.Type == runtime.ConversationEventStatus {
+		s.detail.live.Reset(msg.event.Step)
+		return tea.Batch(wait, s.loadConversationDetail(msg.id))
+	}
+
+	if s.detail.AppendEvent(msg.event) {
+		return tea.Batch(wait, s.loadConversationDetail(msg.id))
+	}
+
+	return wait
+}
+
+func waitForConversationEvent(id uuid.UUID, events <-chan runtime.ConversationEvent) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		return conversationEventMsg{id: id, event: event, closed: !ok}
+	}
 }
 
 type conversationListLoadedMsg struct {
@@ -289,6 +393,12 @@ type conversationListLoadedMsg struct {
 	err      error
 }
 
+type conversationEventMsg struct {
+	id     uuid.UUID
+	event  runtime.ConversationEvent
+	closed bool
+}
+
 type conversationDetailLoadedMsg struct {
 	id           uuid.UUID
 	conversation *agent.Conversation
@@ -3,10 +3,14 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	mcpproto "github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog/log"
 	runtimetypes "github.com/vanclief/agent-composer/runtime/types"
 	"github.com/vanclief/ez"
 )
@@ -15,6 +19,7 @@ type Mux struct {
 	clients      []*client.Client
 	toolToClient map[string]int
 	mergedTools  []runtimetypes.ToolDefinition
+	schemas      map[string]*runtimetypes.Schema // Compiled input schemas by tool name
 }
 
 // NewMux starts initialized clients list (already started/initialized) and builds an index.
@@ -42,6 +47,7 @@ func (m *Mux) refreshTools(ctx context.Context) error {
 
 	merged := make([]runtimetypes.ToolDefinition, 0, 16)
 	toolToClient := make(map[string]int)
+	schemas := make(map[string]*runtimetypes.Schema)
 
 	for clientIndex, mc := range m.clients {
 		result, err := mc.ListTools(ctx, mcpproto.ListToolsRequest{})
@@ -71,12 +77,21 @@ func (m *Mux) refreshTools(ctx context.Context) error {
 			if !exists {
 				toolToClient[tool.Name] = clientIndex
 				merged = append(merged, converted)
+
+				// Calls to tools with a schema we can't compile are not validated
+				schema, compileErr := runtimetypes.CompileSchema(schemaMap)
+				if compileErr != nil {
+					log.Warn().Err(compileErr).Str("tool", tool.Name).Msg("Failed to compile tool input schema")
+				} else {
+					schemas[tool.Name] = schema
+				}
 			}
 		}
 	}
 
 	m.mergedTools = merged
 	m.toolToClient = toolToClient
+	m.schemas = schemas
 	return nil
 }
 
@@ -85,7 +100,62 @@ func (m *Mux) ListTools(_ context.Context) ([]runtimetypes.ToolDefinition, error
 	return m.mergedTools, nil
 }
 
+// ValidateArguments checks the arguments of a call against the input schema of its
+// tool before it is dispatched. Missing arguments are read as an empty object.
+func (m *Mux) ValidateArguments(call *runtimetypes.ToolCall) error {
+	const op = "mcp.Mux.ValidateArguments"
+
+	if call == nil {
+		return ez.New(op, ez.EINVALID, "nil tool call", nil)
+	}
+
+	_, exists := m.toolToClient[call.Name]
+	if !exists {
+		return toolError(op, runtimetypes.ToolErrorUnknownTool, call.Name, fmt.Sprintf("unknown tool: %s", call.Name), nil)
+	}
+
+	arguments := strings.TrimSpace(call.Arguments)
+	if arguments == "" {
+		arguments = "{}"
+	}
+
+	value, err := runtimetypes.DecodeJSON(arguments)
+	if err != nil {
+		return toolError(op, runtimetypes.ToolErrorInvalidArguments, call.Name, "arguments are "+ez.ErrorMessage(err), err)
+	}
+
+	schema, ok := m.schemas[call.Name]
+	if !ok {
+		return nil
+	}
+
+	err = schema.Validate(value)
+	if err != nil {
+		errMsg := fmt.Sprintf("arguments are not valid for the %s input schema: %s", call.Name, err)
+		return toolError(op, runtimetypes.ToolErrorInvalidArguments, call.Name, errMsg, err)
+	}
+
+	return nil
+}
+
+// toolError returns a classified tool call failure, see runtimetypes.ToolErrorKind.
+func toolError(op string, kind runtimetypes.ToolErrorKind, toolName, msg string, err error) error {
+	toolErr := &runtimetypes.ToolError{Kind: kind, Tool: toolName, Err: err}
+	if err == nil {
+		toolErr.Err = errors.New(msg)
+	}
+
+	var schemaErr *runtimetypes.SchemaError
+	if errors.As(err, &schemaErr) {
+		toolErr.Violations = schemaErr.Violations
+	}
+
+	return ez.New(op, kind.Code(), msg, toolErr)
+}
+
 // CallTool routes a call by tool name to the owning MCP client and returns a text payload for your LLM transcript.
+// Failures are classified with a runtimetypes.ToolError, calls the tool itself
+// reports as failed return their payload along with the error.
 func (m *Mux) CallTool(ctx context.Context, call *runtimetypes.ToolCall) (string, error) {
 	const op = "mcp.Mux.CallTool"
 
@@ -95,14 +165,14 @@ func (m *Mux) CallTool(ctx context.Context, call *runtimetypes.ToolCall) (string
 
 	clientIndex, exists := m.toolToClient[call.Name]
 	if !exists {
-		return "", ez.New(op, ez.ENOTFOUND, fmt.Sprintf("unknown tool: %s", call.Name), nil)
+		return "", toolError(op, runtimetypes.ToolErrorUnknownTool, call.Name, fmt.Sprintf("unknown tool: %s", call.Name), nil)
 	}
 
 	var argsMap map[string]any
 	if len(call.Arguments) > 0 {
 		unmarshalErr := json.Unmarshal([]byte(call.Arguments), &argsMap)
 		if unmarshalErr != nil {
-			return "", ez.Wrap(op, unmarshalErr)
+			return "", toolError(op, runtimetypes.ToolErrorInvalidArguments, call.Name, "arguments are not a JSON object", unmarshalErr)
 		}
 	}
 
@@ -114,10 +184,58 @@ func (m *Mux) CallTool(ctx context.Context, call *runtimetypes.ToolCall) (string
 	}
 
 	result, err := m.clients[clientIndex].CallTool(ctx, request)
+	if err != nil {
+		// Errors that reached the server came back as a response, the rest never got there
+		kind := runtimetypes.ToolErrorFailed
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) {
+			kind = runtimetypes.ToolErrorInfrastructure
+		}
+
+		return "", toolError(op, kind, call.Name, err.Error(), err)
+	}
+
+	payload, err := toolPayload(result)
 	if err != nil {
 		return "", ez.Wrap(op, err)
 	}
 
+	if result.IsError {
+		toolErr := &runtimetypes.ToolError{
+			Kind:   resultErrorKind(result),
+			Tool:   call.Name,
+			Output: payload,
+			Err:    errors.New("the tool reported an error"),
+		}
+
+		return payload, ez.New(op, toolErr.Kind.Code(), "the tool reported an error", toolErr)
+	}
+
+	return payload, nil
+}
+
+// resultErrorKind returns the kind of a tool error result, as reported by the server
+// in its _meta. Servers can only report kinds the model can recover from.
+func resultErrorKind(result *mcpproto.CallToolResult) runtimetypes.ToolErrorKind {
+	if result.Meta == nil {
+		return runtimetypes.ToolErrorFailed
+	}
+
+	value, _ := result.Meta.AdditionalFields[runtimetypes.ToolErrorKindMeta].(string)
+
+	kind := runtimetypes.ToolErrorKind(value)
+	if kind.Validate() != nil || !kind.Recoverable() {
+		return runtimetypes.ToolErrorFailed
+	}
+
+	return kind
+}
+
+// toolPayload returns the text content of a tool result, or its structured content
+// as JSON when there is no text.
+func toolPayload(result *mcpproto.CallToolResult) (string, error) {
+	const op = "mcp.toolPayload"
+
 	// Prefer text content; fall back to structured.
 	var combined string
 	for i := range result.Content {
@@ -136,7 +254,7 @@ func (m *Mux) CallTool(ctx context.Context, call *runtimetypes.ToolCall) (string
 	if result.StructuredContent != nil {
 		bytesOut, marshalErr := json.Marshal(result.StructuredContent)
 		if marshalErr != nil {
-			return "", ez.Wrap(op, marshalErr)
+			return "", ez.New(op, ez.EINTERNAL, "failed to encode structured content", marshalErr)
 		}
 		return string(bytesOut), nil
 	}
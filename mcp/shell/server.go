@@ -3,6 +3,7 @@ package shell
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,12 +11,13 @@ import (
 
 	mcpproto "github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	runtimetypes "github.com/vanclief/agent-composer/runtime/types"
 	"github.com/vanclief/ez"
 )
 
 type shellRunArgs struct {
-	Command string `json:"command"        jsonschema:"required" jsonschema_description:"Full shell command to execute using bash -lc"`
-	Workdir string `json:"workdir"        jsonschema_description:"Optional working directory"`
+	Command string `json:"command"           jsonschema:"required" jsonschema_description:"Full shell command to execute using bash -lc"`
+	Workdir string `json:"workdir,omitempty" jsonschema_description:"Optional working directory"`
 }
 
 type ShellRunResult struct {
@@ -63,15 +65,18 @@ func NewServer(rootDir string, allowedWorkdirs []string, defaultWorkdir string,
 		mcpproto.WithOutputSchema[ShellRunResult](),
 	)
 
-	srv.AddTool(shellTool, mcpproto.NewStructuredToolHandler(func(
-		ctx context.Context,
-		_ mcpproto.CallToolRequest,
-		args shellRunArgs,
-	) (ShellRunResult, error) {
+	srv.AddTool(shellTool, func(ctx context.Context, request mcpproto.CallToolRequest) (*mcpproto.CallToolResult, error) {
+		var args shellRunArgs
+
+		err := request.BindArguments(&args)
+		if err != nil {
+			return mcpproto.NewToolResultError(fmt.Sprintf("failed to bind arguments: %v", err)), nil
+		}
+
 		// 1) Resolve workdir (this defines `workdir`)
 		workdir, err := resolver.resolve(args.Workdir)
 		if err != nil {
-			return ShellRunResult{}, ez.Wrap(op, err)
+			return mcpproto.NewToolResultError(fmt.Sprintf("tool execution failed: %s", ez.ErrorMessage(err))), nil
 		}
 
 		// 2) Compute effective timeout (this defines `effectiveTimeout`)
@@ -92,17 +97,18 @@ func NewServer(rootDir string, allowedWorkdirs []string, defaultWorkdir string,
 			Command:      args.Command,
 		}
 
-		switch {
-		case outcome.TimedOut:
-			return result, ez.New(op, ez.ERESOURCEEXHAUSTED, "command timed out", err)
+		// 3) Commands that exit non-zero or time out keep their output, flagged as an error
+		toolResult := mcpproto.NewToolResultStructuredOnly(result)
+		toolResult.IsError = err != nil
 
-		case err != nil:
-			return result, err
-
-		default:
-			return result, nil
+		if outcome.TimedOut {
+			toolResult.Meta = &mcpproto.Meta{
+				AdditionalFields: map[string]any{runtimetypes.ToolErrorKindMeta: string(runtimetypes.ToolErrorTimeout)},
+			}
 		}
-	}))
+
+		return toolResult, nil
+	})
 
 	return srv, nil
 }
@@ -20,33 +20,62 @@ var (
 	_ relational.DBModel        = (*Conversation)(nil)
 )
 
+// DefaultMaxSteps bounds the inference steps of a conversation that doesn't set its
+// own limit.
+const DefaultMaxSteps = 300
+
 type Conversation struct {
 	bun.BaseModel `bun:"table:conversations"`
 
-	ID                     uuid.UUID              `bun:",pk,type:uuid" json:"id"`
-	AgentSpecID            uuid.UUID              `bun:"type:uuid" json:"agent_spec_id"`
-	SessionID              string                 `json:"session_id,omitempty"`
-	AgentName              string                 `json:"agent_name"`
-	Provider               LLMProvider            `json:"provider"`
-	Model                  string                 `json:"model"`
-	ReasoningEffort        types.ReasoningEffort  `json:"reasoning_effort"`
-	Instructions           string                 `json:"instructions"`
-	Tools                  []types.ToolDefinition `bun:"type:jsonb,nullzero" json:"-"`
-	Messages               []types.Message        `bun:"type:jsonb,nullzero" json:"messages"`
-	Status                 ConversationStatus     `json:"status"`
-	InputTokens            int64                  `json:"input_tokens"`
-	OutputTokens           int64                  `json:"output_tokens"`
-	CachedTokens           int64                  `json:"cached_tokens"`
-	Cost                   int64                  `json:"cost"`
-	CreatedAt              time.Time              `json:"created_at"`
-	AutoCompact            bool                   `json:"auto_compact"`
-	CompactAtPercent       int                    `json:"compact_at_percent"`
-	CompactionPrompt       string                 `json:"compaction_prompt"`
-	CompactCount           int                    `json:"compact_count"`
-	ShellAccess            bool                   `json:"shell_access"`
-	WebSearch              bool                   `json:"web_search"`
-	StructuredOutput       bool                   `json:"structured_output"`
-	StructuredOutputSchema map[string]any         `bun:"type:jsonb,nullzero" json:"structured_output_schema"`
+	ID                         uuid.UUID              `bun:",pk,type:uuid" json:"id"`
+	AgentSpecID                uuid.UUID              `bun:"type:uuid" json:"agent_spec_id"`
+	SessionID                  string                 `json:"session_id,omitempty"`
+	AgentName                  string                 `json:"agent_name"`
+	Provider                   LLMProvider            `json:"provider"`
+	Model                      string                 `json:"model"`
+	BaseURL                    string                 `json:"base_url"`
+	ReasoningEffort            types.ReasoningEffort  `json:"reasoning_effort"`
+	Instructions               string                 `json:"instructions"`
+	Tools                      []types.ToolDefinition `bun:"type:jsonb,nullzero" json:"-"`
+	Messages                   []types.Message        `bun:"-" json:"messages"` // Stored in conversation_messages, see LoadMessages
+	Status                     ConversationStatus     `json:"status"`
+	StatusReason               string                 `json:"status_reason,omitempty"`
+	HeartbeatAt                *time.Time             `bun:",nullzero" json:"heartbeat_at,omitempty"`
+	InputTokens                int64                  `json:"input_tokens"`
+	OutputTokens               int64                  `json:"output_tokens"`
+	CachedTokens               int64                  `json:"cached_tokens"`
+	Cost                       float64                `json:"cost"` // Cents, unrounded
+	CreatedAt                  time.Time              `json:"created_at"`
+	AutoCompact                bool                   `json:"auto_compact"`
+	CompactAtPercent           int                    `json:"compact_at_percent"`
+	CompactionPrompt           string                 `json:"compaction_prompt"`
+	CompactCount               int                    `json:"compact_count"`
+	ShellAccess                bool                   `json:"shell_access"`
+	WebSearch                  bool                   `json:"web_search"`
+	StructuredOutput           bool                   `json:"structured_output"`
+	StructuredOutputSchema     map[string]any         `bun:"type:jsonb,nullzero" json:"structured_output_schema"`
+	StructuredOutputRepairs    int                    `json:"structured_output_repairs"`
+	Result                     any                    `bun:"type:jsonb,nullzero" json:"result,omitempty"` // Validated structured output of the final answer
+	FallbackModels             []ModelRef             `bun:"type:jsonb,nullzero" json:"fallback_models,omitempty"`
+	RecoveryPolicy             RecoveryPolicy         `json:"recovery_policy"`
+	HistoryMode                HistoryMode            `json:"history_mode"`
+	MaxCostCents               int64                  `json:"max_cost_cents"`
+	MaxTotalTokens             int64                  `json:"max_total_tokens"`
+	MaxSteps                   int                    `json:"max_steps"`
+	StepCount                  int                    `json:"step_count"` // Inference steps taken over every run
+	MaxParallelToolCalls       int                    `json:"max_parallel_tool_calls"`
+	SequentialTools            []string               `bun:"type:jsonb,nullzero" json:"sequential_tools,omitempty"`
+	MaxConsecutiveToolFailures int                    `json:"max_consecutive_tool_failures"`
+	LoopWindow                 int                    `json:"loop_window"`
+	LoopThreshold              int                    `json:"loop_threshold"`
+	LoopAction                 LoopAction             `json:"loop_action"`
+	InvalidToolCalls           int                    `json:"invalid_tool_calls"` // Tool calls rejected by their input schema
+	ProviderRetries            int                    `json:"provider_retries"`
+	ProviderErrorClass         types.ErrorClass       `json:"provider_error_class,omitempty"`
+	LastResponseID             string                 `json:"last_response_id,omitempty"`
+	LastResponseMessages       int                    `json:"last_response_messages,omitempty"`
+
+	savedMessages int // Messages already stored, only the ones after them are inserted
 }
 
 // ---- Constructor ----
@@ -60,24 +89,38 @@ func NewConversation(agentSpec *Spec, messages []types.Message) (*Conversation,
 	}
 
 	conversation := &Conversation{
-		ID:                     id,
-		AgentSpecID:            agentSpec.ID,
-		AgentName:              agentSpec.Name,
-		Provider:               agentSpec.Provider,
-		Model:                  agentSpec.Model,
-		ReasoningEffort:        agentSpec.ReasoningEffort,
-		Instructions:           agentSpec.Instructions,
-		Messages:               messages,
-		Status:                 ConversationStatusQueued,
-		CreatedAt:              time.Now().UTC(),
-		AutoCompact:            agentSpec.AutoCompact,
-		CompactAtPercent:       agentSpec.CompactAtPercent,
-		CompactionPrompt:       agentSpec.CompactionPrompt,
-		CompactCount:           0,
-		ShellAccess:            agentSpec.ShellAccess,
-		WebSearch:              agentSpec.WebSearch,
-		StructuredOutput:       agentSpec.StructuredOutput,
-		StructuredOutputSchema: agentSpec.StructuredOutputSchema,
+		ID:                         id,
+		AgentSpecID:                agentSpec.ID,
+		AgentName:                  agentSpec.Name,
+		Provider:                   agentSpec.Provider,
+		Model:                      agentSpec.Model,
+		BaseURL:                    agentSpec.BaseURL,
+		ReasoningEffort:            agentSpec.ReasoningEffort,
+		Instructions:               agentSpec.Instructions,
+		Messages:                   messages,
+		Status:                     ConversationStatusQueued,
+		CreatedAt:                  time.Now().UTC(),
+		AutoCompact:                agentSpec.AutoCompact,
+		CompactAtPercent:           agentSpec.CompactAtPercent,
+		CompactionPrompt:           agentSpec.CompactionPrompt,
+		CompactCount:               0,
+		ShellAccess:                agentSpec.ShellAccess,
+		WebSearch:                  agentSpec.WebSearch,
+		StructuredOutput:           agentSpec.StructuredOutput,
+		StructuredOutputSchema:     agentSpec.StructuredOutputSchema,
+		StructuredOutputRepairs:    agentSpec.StructuredOutputRepairs,
+		FallbackModels:             agentSpec.FallbackModels,
+		RecoveryPolicy:             agentSpec.RecoveryPolicy,
+		HistoryMode:                agentSpec.HistoryMode,
+		MaxCostCents:               agentSpec.MaxCostCents,
+		MaxTotalTokens:             agentSpec.MaxTotalTokens,
+		MaxSteps:                   agentSpec.MaxSteps,
+		MaxParallelToolCalls:       agentSpec.MaxParallelToolCalls,
+		SequentialTools:            agentSpec.SequentialTools,
+		MaxConsecutiveToolFailures: agentSpec.MaxConsecutiveToolFailures,
+		LoopWindow:                 agentSpec.LoopWindow,
+		LoopThreshold:              agentSpec.LoopThreshold,
+		LoopAction:                 agentSpec.LoopAction,
 	}
 
 	err = conversation.Validate()
@@ -113,9 +156,75 @@ func (c *Conversation) Validate() error {
 		return ez.New(op, ez.EINVALID, "compact_at_percent must be between 1 and 100", nil)
 	}
 
+	if c.MaxCostCents < 0 {
+		return ez.New(op, ez.EINVALID, "max_cost_cents must be >= 0", nil)
+	}
+
+	if c.MaxTotalTokens < 0 {
+		return ez.New(op, ez.EINVALID, "max_total_tokens must be >= 0", nil)
+	}
+
+	if c.MaxSteps < 0 {
+		return ez.New(op, ez.EINVALID, "max_steps must be >= 0", nil)
+	}
+
+	if c.MaxParallelToolCalls < 0 {
+		return ez.New(op, ez.EINVALID, "max_parallel_tool_calls must be >= 0", nil)
+	}
+
+	if c.MaxConsecutiveToolFailures < 0 {
+		return ez.New(op, ez.EINVALID, "max_consecutive_tool_failures must be >= 0", nil)
+	}
+
+	if c.LoopWindow < 0 {
+		return ez.New(op, ez.EINVALID, "loop_window must be >= 0", nil)
+	}
+
+	if c.LoopThreshold < 0 || c.LoopThreshold == 1 {
+		return ez.New(op, ez.EINVALID, "loop_threshold must be 0 or at least 2", nil)
+	}
+
+	if err := c.LoopAction.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if c.StructuredOutputRepairs < 0 {
+		return ez.New(op, ez.EINVALID, "structured_output_repairs must be >= 0", nil)
+	}
+
 	return nil
 }
 
+// ---- Methods ----
+
+// StepLimit returns the number of inference steps the conversation may take.
+func (c *Conversation) StepLimit() int {
+	if c.MaxSteps > 0 {
+		return c.MaxSteps
+	}
+
+	return DefaultMaxSteps
+}
+
+// ExhaustedBudget reports which of the cost, token and step budgets the conversation
+// has spent, or an empty string. They accumulate over every run of the conversation.
+func (c *Conversation) ExhaustedBudget() string {
+	totalTokens := c.InputTokens + c.OutputTokens + c.CachedTokens
+
+	switch {
+	case c.MaxCostCents > 0 && c.Cost >= float64(c.MaxCostCents):
+		return fmt.Sprintf("cost budget exceeded: spent %.2f of %d cents", c.Cost, c.MaxCostCents)
+	case c.MaxTotalTokens > 0 && totalTokens >= c.MaxTotalTokens:
+		return fmt.Sprintf("token budget exceeded: used %d of %d tokens", totalTokens, c.MaxTotalTokens)
+	case c.StepCount >= c.StepLimit():
+		return fmt.Sprintf("step budget exceeded: ran %d of %d steps", c.StepCount, c.StepLimit())
+	}
+
+	return ""
+}
+
+// ---- CRUD ----
+
 func (c *Conversation) Insert(ctx context.Context, db bun.IDB) error {
 	const op = "Conversation.Insert"
 
@@ -136,15 +245,47 @@ func (c *Conversation) Insert(ctx context.Context, db bun.IDB) error {
 		return ez.Wrap(op, err)
 	}
 
-	_, err = db.NewInsert().Model(c).Exec(ctx)
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(c).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return appendMessages(ctx, tx, c.ID, 0, c.Messages)
+	})
 	if err != nil {
 		log.Debug().Err(err).Msg("Failed to insert conversation")
 		return ez.Wrap(op, err)
 	}
 
+	c.savedMessages = len(c.Messages)
+
 	return nil
 }
 
+// updatedColumns are the columns of a conversation that change while it runs, the
+// rest are set when it is created.
+var updatedColumns = []string{
+	"status",
+	"status_reason",
+	"input_tokens",
+	"output_tokens",
+	"cached_tokens",
+	"cost",
+	"compact_count",
+	"result",
+	"max_cost_cents",
+	"max_total_tokens",
+	"max_steps",
+	"step_count",
+	"max_parallel_tool_calls",
+	"invalid_tool_calls",
+	"provider_retries",
+	"provider_error_class",
+	"last_response_id",
+	"last_response_messages",
+}
+
 func (c *Conversation) Update(ctx context.Context, db bun.IDB) error {
 	const op = "Conversation.Update"
 
@@ -157,14 +298,105 @@ func (c *Conversation) Update(ctx context.Context, db bun.IDB) error {
 		return ez.Wrap(op, err)
 	}
 
-	_, err = db.NewUpdate().Model(c).WherePK().Exec(ctx)
+	// The transcript is append only, only the messages added since the last save are written
+	if len(c.Messages) < c.savedMessages {
+		return ez.New(op, ez.EINVALID, "stored messages can't be removed", nil)
+	}
+
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// Only the columns a run changes are written, the heartbeat is owned by the running job, see Heartbeat
+		_, err := tx.NewUpdate().Model(c).Column(updatedColumns...).WherePK().Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return appendMessages(ctx, tx, c.ID, c.savedMessages, c.Messages[c.savedMessages:])
+	})
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	c.savedMessages = len(c.Messages)
+
+	return nil
+}
+
+// UpdateTools stores the tools a run gives the model.
+func (c *Conversation) UpdateTools(ctx context.Context, db bun.IDB) error {
+	const op = "Conversation.UpdateTools"
+
+	if c.ID == uuid.Nil {
+		return ez.New(op, ez.EINVALID, "id is required", nil)
+	}
+
+	_, err := db.NewUpdate().Model(c).Column("tools").WherePK().Exec(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// Heartbeat renews the lease of a running conversation. Runs whose heartbeat is
+// older than the lease are considered dead and get recovered.
+func (c *Conversation) Heartbeat(ctx context.Context, db bun.IDB) error {
+	const op = "Conversation.Heartbeat"
+
+	if c.ID == uuid.Nil {
+		return ez.New(op, ez.EINVALID, "id is required", nil)
+	}
+
+	now := time.Now().UTC()
+
+	_, err := db.NewUpdate().
+		Model((*Conversation)(nil)).
+		Set("heartbeat_at = ?", now).
+		Where("id = ?", c.ID).
+		Exec(ctx)
 	if err != nil {
 		return ez.Wrap(op, err)
 	}
 
+	c.HeartbeatAt = &now
+
 	return nil
 }
 
+// ClaimLease takes over the lease of a conversation whose heartbeat is older than
+// staleBefore. It reports false when another process renewed or claimed it first.
+func (c *Conversation) ClaimLease(ctx context.Context, db bun.IDB, staleBefore time.Time) (bool, error) {
+	const op = "Conversation.ClaimLease"
+
+	if c.ID == uuid.Nil {
+		return false, ez.New(op, ez.EINVALID, "id is required", nil)
+	}
+
+	now := time.Now().UTC()
+
+	result, err := db.NewUpdate().
+		Model((*Conversation)(nil)).
+		Set("heartbeat_at = ?", now).
+		Where("id = ?", c.ID).
+		Where("COALESCE(heartbeat_at, created_at) < ?", staleBefore).
+		Exec(ctx)
+	if err != nil {
+		return false, ez.Wrap(op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, ez.Wrap(op, err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	c.HeartbeatAt = &now
+
+	return true, nil
+}
+
 func (c *Conversation) Delete(ctx context.Context, db bun.IDB) error {
 	const op = "Conversation.Delete"
 
@@ -172,7 +404,18 @@ func (c *Conversation) Delete(ctx context.Context, db bun.IDB) error {
 		return ez.New(op, ez.EINVALID, "id is required", errors.New("nil uuid"))
 	}
 
-	_, err := db.NewDelete().Model(c).WherePK().Exec(ctx)
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*ConversationMessage)(nil)).
+			Where("conversation_id = ?", c.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().Model(c).WherePK().Exec(ctx)
+		return err
+	})
 	if err != nil {
 		return ez.Wrap(op, err)
 	}
@@ -199,18 +442,39 @@ func (c *Conversation) Clone(ctx context.Context, db bun.IDB, discardMessages bo
 	clone.InputTokens = 0
 	clone.OutputTokens = 0
 	clone.CachedTokens = 0
+	clone.Cost = 0
+	clone.StepCount = 0
+	clone.StatusReason = ""
+	clone.HeartbeatAt = nil
+	clone.ProviderRetries = 0
+	clone.ProviderErrorClass = ""
+	clone.Result = nil
+	clone.InvalidToolCalls = 0
 
 	if discardMessages {
 		clone.Messages = []types.Message{*types.NewSystemMessage(clone.Instructions)}
+		clone.LastResponseID = ""
+		clone.LastResponseMessages = 0
 	} else if len(c.Messages) > 0 {
 		clone.Messages = append([]types.Message(nil), c.Messages...)
 	}
 
-	_, err = db.NewInsert().Model(&clone).Exec(ctx)
+	clone.savedMessages = 0
+
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(&clone).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return appendMessages(ctx, tx, clone.ID, 0, clone.Messages)
+	})
 	if err != nil {
 		return nil, ez.Wrap(op, err)
 	}
 
+	clone.savedMessages = len(clone.Messages)
+
 	return &clone, nil
 }
 
@@ -231,6 +495,12 @@ func GetConversationByID(ctx context.Context, db bun.IDB, id uuid.UUID) (*Conver
 		}
 		return nil, ez.Wrap(op, err)
 	}
+
+	err = LoadMessages(ctx, db, conversation)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
 	return conversation, nil
 }
 
@@ -245,6 +515,39 @@ func GetConversationsBySpecID(ctx context.Context, db bun.IDB, agentSpecID uuid.
 	if err != nil {
 		return nil, ez.Wrap(op, err)
 	}
+
+	err = LoadMessages(ctx, db, conversations...)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return conversations, nil
+}
+
+// GetOrphanedConversations returns the queued or running conversations without an
+// active job whose heartbeat is older than staleBefore. Conversations that never
+// got a heartbeat are judged by their creation time.
+func GetOrphanedConversations(ctx context.Context, db bun.IDB, staleBefore time.Time) ([]*Conversation, error) {
+	const op = "agent.GetOrphanedConversations"
+
+	var conversations []*Conversation
+	err := db.NewSelect().
+		Model(&conversations).
+		Where("conversation.status IN (?)", bun.In([]ConversationStatus{ConversationStatusQueued, ConversationStatusRunning})).
+		Where("COALESCE(conversation.heartbeat_at, conversation.created_at) < ?", staleBefore).
+		Where("NOT EXISTS (SELECT 1 FROM conversation_jobs AS job WHERE job.conversation_id = conversation.id AND job.status IN (?))",
+			bun.In([]JobStatus{JobStatusPending, JobStatusRunning})).
+		Order("conversation.id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	err = LoadMessages(ctx, db, conversations...)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
 	return conversations, nil
 }
 
@@ -0,0 +1,382 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/vanclief/ez"
+)
+
+// ConversationJob is a queued run of a conversation. Workers claim pending jobs
+// with SELECT ... FOR UPDATE SKIP LOCKED and hold them through a lease they keep
+// renewing, so any number of processes can share the queue.
+type ConversationJob struct {
+	bun.BaseModel `bun:"table:conversation_jobs"`
+
+	ID              uuid.UUID  `bun:",pk,type:uuid" json:"id"`
+	ConversationID  uuid.UUID  `bun:"type:uuid,notnull" json:"conversation_id"`
+	AgentSpecID     uuid.UUID  `bun:"type:uuid" json:"agent_spec_id"`
+	SessionID       string     `json:"session_id,omitempty"`
+	Status          JobStatus  `bun:",notnull" json:"status"`
+	Priority        int        `bun:",notnull" json:"priority"`
+	Attempts        int        `bun:",notnull" json:"attempts"`
+	MaxAttempts     int        `bun:",notnull" json:"max_attempts"`
+	RunAt           time.Time  `bun:",notnull" json:"run_at"`
+	LockedBy        string     `json:"locked_by,omitempty"`
+	LeaseExpiresAt  *time.Time `bun:",nullzero" json:"lease_expires_at,omitempty"`
+	CancelRequested bool       `json:"cancel_requested"`
+	LastError       string     `json:"last_error,omitempty"`
+	CreatedAt       time.Time  `bun:",notnull" json:"created_at"`
+	FinishedAt      *time.Time `bun:",nullzero" json:"finished_at,omitempty"`
+}
+
+// ---- Constructor ----
+
+func NewConversationJob(conversation *Conversation, priority, maxAttempts int) (*ConversationJob, error) {
+	const op = "agent.NewConversationJob"
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	now := time.Now().UTC()
+
+	job := &ConversationJob{
+		ID:             id,
+		ConversationID: conversation.ID,
+		AgentSpecID:    conversation.AgentSpecID,
+		SessionID:      conversation.SessionID,
+		Status:         JobStatusPending,
+		Priority:       priority,
+		MaxAttempts:    maxAttempts,
+		RunAt:          now,
+		CreatedAt:      now,
+	}
+
+	err = job.Validate()
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return job, nil
+}
+
+// ---- Validation ----
+
+func (j *ConversationJob) Validate() error {
+	const op = "ConversationJob.Validate"
+
+	if j.ConversationID == uuid.Nil {
+		return ez.New(op, ez.EINVALID, "conversation_id is required", nil)
+	}
+
+	if err := j.Status.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if j.MaxAttempts <= 0 {
+		return ez.New(op, ez.EINVALID, "max_attempts must be > 0", nil)
+	}
+
+	return nil
+}
+
+// ---- CRUD ----
+
+func (j *ConversationJob) Insert(ctx context.Context, db bun.IDB) error {
+	const op = "ConversationJob.Insert"
+
+	err := j.Validate()
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	_, err = db.NewInsert().Model(j).Exec(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (j *ConversationJob) Update(ctx context.Context, db bun.IDB) error {
+	const op = "ConversationJob.Update"
+
+	if j.ID == uuid.Nil {
+		return ez.New(op, ez.EINVALID, "id is required", nil)
+	}
+
+	err := j.Validate()
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	_, err = db.NewUpdate().Model(j).WherePK().Exec(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// UpdateLeased persists the job only while workerID still holds its lease, so a
+// worker that lost it can't overwrite the state set by the one that took over.
+func (j *ConversationJob) UpdateLeased(ctx context.Context, db bun.IDB, workerID string) error {
+	const op = "ConversationJob.UpdateLeased"
+
+	err := j.Validate()
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	result, err := db.NewUpdate().
+		Model(j).
+		WherePK().
+		Where("locked_by = ?", workerID).
+		Where("status = ?", JobStatusRunning).
+		Exec(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if affected == 0 {
+		errMsg := fmt.Sprintf("worker %s no longer holds the lease of job %s", workerID, j.ID)
+		return ez.New(op, ez.ECONFLICT, errMsg, nil)
+	}
+
+	return nil
+}
+
+// RenewLease extends the lease held by workerID and reports whether a cancellation
+// was requested for the job in the meantime.
+func (j *ConversationJob) RenewLease(ctx context.Context, db bun.IDB, workerID string, leaseExpiresAt time.Time) (bool, error) {
+	const op = "ConversationJob.RenewLease"
+
+	var cancelRequested bool
+
+	err := db.NewUpdate().
+		Model((*ConversationJob)(nil)).
+		Set("lease_expires_at = ?", leaseExpiresAt).
+		Where("id = ?", j.ID).
+		Where("locked_by = ?", workerID).
+		Where("status = ?", JobStatusRunning).
+		Returning("cancel_requested").
+		Scan(ctx, &cancelRequested)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			errMsg := fmt.Sprintf("worker %s no longer holds the lease of job %s", workerID, j.ID)
+			return false, ez.New(op, ez.ECONFLICT, errMsg, err)
+		}
+		return false, ez.Wrap(op, err)
+	}
+
+	j.LeaseExpiresAt = &leaseExpiresAt
+	j.CancelRequested = cancelRequested
+
+	return cancelRequested, nil
+}
+
+// RequestCancel flags a running job so the worker holding it stops the run on its
+// next lease renewal.
+func (j *ConversationJob) RequestCancel(ctx context.Context, db bun.IDB) error {
+	const op = "ConversationJob.RequestCancel"
+
+	_, err := db.NewUpdate().
+		Model((*ConversationJob)(nil)).
+		Set("cancel_requested = TRUE").
+		Where("id = ?", j.ID).
+		Where("status = ?", JobStatusRunning).
+		Exec(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	j.CancelRequested = true
+
+	return nil
+}
+
+// CancelPending cancels the job if no worker claimed it yet. It reports false when
+// the job is no longer pending.
+func (j *ConversationJob) CancelPending(ctx context.Context, db bun.IDB) (bool, error) {
+	const op = "ConversationJob.CancelPending"
+
+	now := time.Now().UTC()
+
+	result, err := db.NewUpdate().
+		Model((*ConversationJob)(nil)).
+		Set("status = ?", JobStatusCanceled).
+		Set("finished_at = ?", now).
+		Where("id = ?", j.ID).
+		Where("status = ?", JobStatusPending).
+		Exec(ctx)
+	if err != nil {
+		return false, ez.Wrap(op, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, ez.Wrap(op, err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	j.Status = JobStatusCanceled
+	j.FinishedAt = &now
+
+	return true, nil
+}
+
+// ---- Queries ----
+
+// JobClaimOptions limits which pending jobs a worker may claim. Zero values mean
+// no limit.
+type JobClaimOptions struct {
+	MaxRunning           int  // Running jobs across every worker
+	MaxRunningPerSession int  // Running jobs that share a session ID
+	FIFO                 bool // Ignore priorities and claim the oldest job first
+}
+
+// claimLockKey is the advisory lock that serializes claims, so the running job
+// counts the limits are checked against can't change while a job is claimed.
+const claimLockKey = 7_301_126_457
+
+// ClaimConversationJob leases the next pending job to workerID, highest priority
+// first and oldest first within a priority. Jobs whose agent spec or session are
+// at their concurrency limit are skipped, and nothing is claimed while the global
+// limit is reached. It returns nil when no job is ready.
+func ClaimConversationJob(ctx context.Context, db bun.IDB, workerID string, leaseExpiresAt time.Time, opts JobClaimOptions) (*ConversationJob, error) {
+	const op = "agent.ClaimConversationJob"
+
+	var job *ConversationJob
+
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", claimLockKey)
+		if err != nil {
+			return err
+		}
+
+		if opts.MaxRunning > 0 {
+			running, err := tx.NewSelect().
+				Model((*ConversationJob)(nil)).
+				Where("status = ?", JobStatusRunning).
+				Count(ctx)
+			if err != nil {
+				return err
+			}
+
+			if running >= opts.MaxRunning {
+				return nil
+			}
+		}
+
+		now := time.Now().UTC()
+
+		next := tx.NewSelect().
+			Model((*ConversationJob)(nil)).
+			Column("conversation_job.id").
+			Join("LEFT JOIN agent_specs AS spec ON spec.id = conversation_job.agent_spec_id").
+			Where("conversation_job.status = ?", JobStatusPending).
+			Where("conversation_job.run_at <= ?", now).
+			Where(`COALESCE(spec.max_concurrent_conversations, 0) = 0 OR (
+				SELECT COUNT(*) FROM conversation_jobs AS running
+				WHERE running.status = ? AND running.agent_spec_id = conversation_job.agent_spec_id
+			) < spec.max_concurrent_conversations`, JobStatusRunning)
+
+		if opts.MaxRunningPerSession > 0 {
+			next = next.Where(`conversation_job.session_id = '' OR (
+				SELECT COUNT(*) FROM conversation_jobs AS running
+				WHERE running.status = ? AND running.session_id = conversation_job.session_id
+			) < ?`, JobStatusRunning, opts.MaxRunningPerSession)
+		}
+
+		if opts.FIFO {
+			next = next.OrderExpr("conversation_job.run_at ASC, conversation_job.id ASC")
+		} else {
+			next = next.OrderExpr("conversation_job.priority DESC, conversation_job.run_at ASC, conversation_job.id ASC")
+		}
+
+		next = next.Limit(1).For("UPDATE OF conversation_job SKIP LOCKED")
+
+		claimed := new(ConversationJob)
+		err = tx.NewUpdate().
+			Model(claimed).
+			Set("status = ?", JobStatusRunning).
+			Set("locked_by = ?", workerID).
+			Set("lease_expires_at = ?", leaseExpiresAt).
+			Set("attempts = attempts + 1").
+			Where("id = (?)", next).
+			Returning("*").
+			Scan(ctx)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		job = claimed
+		return nil
+	})
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return job, nil
+}
+
+// LockExpiredConversationJobs locks the running jobs whose lease expired before
+// now, skipping the ones another process is already handling. It must run inside
+// a transaction.
+func LockExpiredConversationJobs(ctx context.Context, db bun.IDB, now time.Time) ([]*ConversationJob, error) {
+	const op = "agent.LockExpiredConversationJobs"
+
+	var jobs []*ConversationJob
+	err := db.NewSelect().
+		Model(&jobs).
+		Where("status = ?", JobStatusRunning).
+		Where("lease_expires_at < ?", now).
+		Order("id ASC").
+		For("UPDATE SKIP LOCKED").
+		Scan(ctx)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return jobs, nil
+}
+
+// GetActiveConversationJob returns the pending or running job of a conversation.
+func GetActiveConversationJob(ctx context.Context, db bun.IDB, conversationID uuid.UUID) (*ConversationJob, error) {
+	const op = "agent.GetActiveConversationJob"
+
+	job := new(ConversationJob)
+	err := db.NewSelect().
+		Model(job).
+		Where("conversation_id = ?", conversationID).
+		Where("status IN (?)", bun.In([]JobStatus{JobStatusPending, JobStatusRunning})).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			errMsg := fmt.Sprintf("conversation with ID %s has no active job", conversationID)
+			return nil, ez.New(op, ez.ENOTFOUND, errMsg, err)
+		}
+		return nil, ez.Wrap(op, err)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// ConversationMessage is a message of a conversation transcript. Messages are
+// append only, Seq is their position in the transcript starting at 0.
+type ConversationMessage struct {
+	bun.BaseModel `bun:"table:conversation_messages"`
+
+	ConversationID uuid.UUID         `bun:",pk,type:uuid" json:"conversation_id"`
+	Seq            int               `bun:",pk" json:"seq"`
+	Role           types.MessageRole `bun:",notnull" json:"role"`
+	Message        types.Message     `bun:"type:jsonb,notnull" json:"message"`
+	CreatedAt      time.Time         `bun:",notnull" json:"created_at"`
+}
+
+// appendMessages inserts the messages of the transcript from position from on.
+func appendMessages(ctx context.Context, db bun.IDB, conversationID uuid.UUID, from int, messages []types.Message) error {
+	const op = "agent.appendMessages"
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	rows := make([]ConversationMessage, 0, len(messages))
+	for i, msg := range messages {
+		rows = append(rows, ConversationMessage{
+			ConversationID: conversationID,
+			Seq:            from + i,
+			Role:           msg.Role,
+			Message:        msg,
+			CreatedAt:      now,
+		})
+	}
+
+	_, err := db.NewInsert().Model(&rows).Exec(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// ---- Queries ----
+
+// LoadMessages fills the transcripts of the conversations with a single query.
+func LoadMessages(ctx context.Context, db bun.IDB, conversations ...*Conversation) error {
+	const op = "agent.LoadMessages"
+
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*Conversation, len(conversations))
+	ids := make([]uuid.UUID, 0, len(conversations))
+	for _, conversation := range conversations {
+		conversation.Messages = nil
+		conversation.savedMessages = 0
+
+		byID[conversation.ID] = conversation
+		ids = append(ids, conversation.ID)
+	}
+
+	var rows []ConversationMessage
+	err := db.NewSelect().
+		Model(&rows).
+		Where("conversation_message.conversation_id IN (?)", bun.In(ids)).
+		Order("conversation_message.conversation_id ASC", "conversation_message.seq ASC").
+		Scan(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	for _, row := range rows {
+		conversation := byID[row.ConversationID]
+		conversation.Messages = append(conversation.Messages, row.Message)
+		conversation.savedMessages++
+	}
+
+	return nil
+}
@@ -10,6 +10,10 @@ const (
 	ConversationStatusSucceeded ConversationStatus = "succeeded"
 	ConversationStatusFailed    ConversationStatus = "failed"
 	ConversationStatusCanceled  ConversationStatus = "canceled"
+	// ConversationStatusBudgetExceeded marks runs halted by their cost, token or step budget.
+	ConversationStatusBudgetExceeded ConversationStatus = "budget_exceeded"
+	// ConversationStatusLooping marks runs stopped by the loop detector.
+	ConversationStatusLooping ConversationStatus = "looping"
 )
 
 var conversationStatusSet = enums.Set([]ConversationStatus{
@@ -18,8 +22,15 @@ var conversationStatusSet = enums.Set([]ConversationStatus{
 	ConversationStatusSucceeded,
 	ConversationStatusFailed,
 	ConversationStatusCanceled,
+	ConversationStatusBudgetExceeded,
+	ConversationStatusLooping,
 })
 
+// IsTerminal reports whether the conversation has stopped running.
+func (s ConversationStatus) IsTerminal() bool {
+	return s != ConversationStatusQueued && s != ConversationStatusRunning
+}
+
 func (s ConversationStatus) Validate() error {
 	return enums.Validate(s, conversationStatusSet)
 }
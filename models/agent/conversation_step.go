@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/vanclief/agent-composer/models/hook"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// ConversationStep is the audit record of an LLM call or a tool execution of a
+// conversation. Steps are written as they finish and never updated, their time
+// ordered IDs are assigned on insert.
+type ConversationStep struct {
+	bun.BaseModel `bun:"table:conversation_steps"`
+
+	ID                   uuid.UUID           `bun:",pk,type:uuid" json:"id"`
+	ConversationID       uuid.UUID           `bun:"type:uuid,notnull" json:"conversation_id"`
+	Step                 int                 `bun:",notnull" json:"step"` // Inference step, numbered over every run of the conversation
+	Kind                 StepKind            `bun:",notnull" json:"kind"`
+	Provider             LLMProvider         `json:"provider,omitempty"`
+	Model                string              `json:"model,omitempty"`
+	EstimatedInputTokens int                 `json:"estimated_input_tokens,omitempty"`
+	InputTokens          int64               `json:"input_tokens"`
+	OutputTokens         int64               `json:"output_tokens"`
+	CachedTokens         int64               `json:"cached_tokens"`
+	Cost                 float64             `json:"cost"` // Cents, unrounded
+	ToolName             string              `json:"tool_name,omitempty"`
+	ToolCallID           string              `json:"tool_call_id,omitempty"`
+	ExitCode             *int                `json:"exit_code,omitempty"`
+	ToolError            types.ToolErrorKind `json:"tool_error,omitempty"`
+	Hooks                []StepHook          `bun:"type:jsonb,nullzero" json:"hooks,omitempty"`
+	Error                string              `json:"error,omitempty"`
+	StartedAt            time.Time           `bun:",notnull" json:"started_at"`
+	FinishedAt           time.Time           `bun:",notnull" json:"finished_at"`
+	LatencyMS            int64               `json:"latency_ms"`
+}
+
+// StepHook is the outcome of a hook that ran during a step.
+type StepHook struct {
+	HookID    uuid.UUID      `json:"hook_id"`
+	EventType hook.EventType `json:"event_type"`
+	ExitCode  int            `json:"exit_code"`
+	Blocked   bool           `json:"blocked"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// ---- Constructor ----
+
+func NewConversationStep(conversationID uuid.UUID, step int, kind StepKind) *ConversationStep {
+	return &ConversationStep{
+		ConversationID: conversationID,
+		Step:           step,
+		Kind:           kind,
+		StartedAt:      time.Now().UTC(),
+	}
+}
+
+// ---- Validation ----
+
+func (s *ConversationStep) Validate() error {
+	const op = "ConversationStep.Validate"
+
+	if s.ConversationID == uuid.Nil {
+		return ez.New(op, ez.EINVALID, "conversation_id is required", nil)
+	}
+
+	if err := s.Kind.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// ---- Methods ----
+
+// Finish stamps the end of the step and the error that ended it, if any.
+func (s *ConversationStep) Finish(err error) {
+	s.FinishedAt = time.Now().UTC()
+	s.LatencyMS = s.FinishedAt.Sub(s.StartedAt).Milliseconds()
+
+	if err != nil {
+		s.Error = ez.ErrorMessage(err)
+	}
+}
+
+// ---- CRUD ----
+
+func (s *ConversationStep) Insert(ctx context.Context, db bun.IDB) error {
+	const op = "ConversationStep.Insert"
+
+	err := s.Validate()
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if s.ID == uuid.Nil {
+		s.ID, err = uuid.NewV7()
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
+	_, err = db.NewInsert().Model(s).Exec(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// ---- Pagination helpers ----
+
+func (s ConversationStep) GetCursor() string {
+	return s.ID.String()
+}
+
+func (s ConversationStep) GetSortField() string {
+	return "conversation_step.id"
+}
+
+func (s ConversationStep) GetSortValue() interface{} {
+	return s.ID
+}
+
+func (s ConversationStep) GetUniqueField() string {
+	return "conversation_step.id"
+}
+
+func (s ConversationStep) GetUniqueValue() interface{} {
+	return s.ID
+}
@@ -0,0 +1,33 @@
+package agent
+
+import "github.com/vanclief/compose/primitives/enums"
+
+// HistoryMode decides how the conversation history reaches providers that can keep
+// it server-side, e.g. the OpenAI Responses API.
+type HistoryMode string
+
+const (
+	// HistoryModeServerSide stores responses on the provider and only sends the
+	// messages added since the last one.
+	HistoryModeServerSide HistoryMode = "server_side"
+	// HistoryModeStateless asks the provider not to store anything and sends the
+	// full history every time, e.g. for zero data retention accounts.
+	HistoryModeStateless HistoryMode = "stateless"
+)
+
+var historyModeSet = enums.Set([]HistoryMode{
+	HistoryModeServerSide,
+	HistoryModeStateless,
+})
+
+func (m HistoryMode) Validate() error {
+	return enums.Validate(m, historyModeSet)
+}
+
+func (m HistoryMode) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(m, historyModeSet)
+}
+
+func (m *HistoryMode) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, m, historyModeSet)
+}
@@ -0,0 +1,38 @@
+package agent
+
+import "github.com/vanclief/compose/primitives/enums"
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCanceled  JobStatus = "canceled"
+)
+
+var jobStatusSet = enums.Set([]JobStatus{
+	JobStatusPending,
+	JobStatusRunning,
+	JobStatusSucceeded,
+	JobStatusFailed,
+	JobStatusCanceled,
+})
+
+// IsActive reports whether the job is waiting for or held by a worker.
+func (s JobStatus) IsActive() bool {
+	return s == JobStatusPending || s == JobStatusRunning
+}
+
+func (s JobStatus) Validate() error {
+	return enums.Validate(s, jobStatusSet)
+}
+
+func (s JobStatus) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(s, jobStatusSet)
+}
+
+func (s *JobStatus) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, s, jobStatusSet)
+}
@@ -5,13 +5,24 @@ import "github.com/vanclief/compose/primitives/enums"
 type LLMProvider string
 
 const (
-	LLMProviderOpenAI LLMProvider = "open_ai"
+	LLMProviderOpenAI           LLMProvider = "open_ai"
+	LLMProviderAnthropic        LLMProvider = "anthropic"
+	LLMProviderOpenAICompatible LLMProvider = "open_ai_compatible"
 )
 
 var llmProviderSet = enums.Set([]LLMProvider{
 	LLMProviderOpenAI,
+	LLMProviderAnthropic,
+	LLMProviderOpenAICompatible,
 })
 
+// RegisterLLMProvider adds a provider to the set of accepted values so specs can
+// use providers registered outside this module. It is not safe for concurrent use
+// and must be called during initialization.
+func RegisterLLMProvider(provider LLMProvider) {
+	llmProviderSet[provider] = struct{}{}
+}
+
 func (e LLMProvider) Validate() error {
 	return enums.Validate(e, llmProviderSet)
 }
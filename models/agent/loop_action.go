@@ -0,0 +1,34 @@
+package agent
+
+import "github.com/vanclief/compose/primitives/enums"
+
+// LoopAction decides what happens when the loop detector finds an agent repeating
+// the same tool calls.
+type LoopAction string
+
+const (
+	// LoopActionWarn tells the model it is going in circles and lets it continue.
+	LoopActionWarn LoopAction = "warn"
+	// LoopActionHook escalates to the loop_detected hooks.
+	LoopActionHook LoopAction = "hook"
+	// LoopActionStop ends the run with the looping status.
+	LoopActionStop LoopAction = "stop"
+)
+
+var loopActionSet = enums.Set([]LoopAction{
+	LoopActionWarn,
+	LoopActionHook,
+	LoopActionStop,
+})
+
+func (a LoopAction) Validate() error {
+	return enums.Validate(a, loopActionSet)
+}
+
+func (a LoopAction) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(a, loopActionSet)
+}
+
+func (a *LoopAction) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, a, loopActionSet)
+}
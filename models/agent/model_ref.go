@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/vanclief/ez"
+)
+
+// ModelRef points to a model served by a provider, e.g. a fallback of a spec.
+type ModelRef struct {
+	Provider LLMProvider `json:"provider"`
+	Model    string      `json:"model"`
+	BaseURL  string      `json:"base_url,omitempty"`
+}
+
+func (m ModelRef) Validate() error {
+	const op = "ModelRef.Validate"
+
+	if err := m.Provider.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if strings.TrimSpace(m.Model) == "" {
+		return ez.New(op, ez.EINVALID, "model is required", nil)
+	}
+
+	if m.Provider == LLMProviderOpenAICompatible && m.BaseURL == "" {
+		return ez.New(op, ez.EINVALID, "base_url is required for open_ai_compatible providers", nil)
+	}
+
+	if m.BaseURL != "" {
+		parsed, err := url.Parse(m.BaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ez.New(op, ez.EINVALID, "base_url must be an absolute http(s) URL", err)
+		}
+	}
+
+	return nil
+}
+
+func (m ModelRef) String() string {
+	return string(m.Provider) + "/" + m.Model
+}
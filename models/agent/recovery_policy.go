@@ -0,0 +1,31 @@
+package agent
+
+import "github.com/vanclief/compose/primitives/enums"
+
+// RecoveryPolicy decides what happens to a conversation whose run was orphaned,
+// e.g. because the process stopped while it was queued or running.
+type RecoveryPolicy string
+
+const (
+	// RecoveryPolicyFail marks orphaned conversations as failed.
+	RecoveryPolicyFail RecoveryPolicy = "fail"
+	// RecoveryPolicyResume continues orphaned conversations from their persisted messages.
+	RecoveryPolicyResume RecoveryPolicy = "resume"
+)
+
+var recoveryPolicySet = enums.Set([]RecoveryPolicy{
+	RecoveryPolicyFail,
+	RecoveryPolicyResume,
+})
+
+func (p RecoveryPolicy) Validate() error {
+	return enums.Validate(p, recoveryPolicySet)
+}
+
+func (p RecoveryPolicy) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(p, recoveryPolicySet)
+}
+
+func (p *RecoveryPolicy) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, p, recoveryPolicySet)
+}
@@ -3,8 +3,10 @@ package agent
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/google/uuid"
@@ -22,25 +24,40 @@ var (
 type Spec struct {
 	bun.BaseModel `bun:"table:agent_specs"`
 
-	ID                     uuid.UUID                    `bun:",pk,type:uuid" json:"id"`
-	Name                   string                       `json:"name"`
-	Provider               LLMProvider                  `json:"provider"`
-	Model                  string                       `json:"model"`
-	ReasoningEffort        runtimetypes.ReasoningEffort `json:"reasoning_effort"`
-	Instructions           string                       `json:"instructions"`
-	AutoCompact            bool                         `json:"auto_compact"`
-	CompactAtPercent       int                          `json:"compact_at_percent"`
-	CompactionPrompt       string                       `json:"compaction_prompt"`
-	ShellAccess            bool                         `json:"shell_access"`
-	WebSearch              bool                         `json:"web_search"`
-	StructuredOutput       bool                         `json:"structured_output"`
-	StructuredOutputSchema map[string]any               `bun:"type:jsonb,nullzero" json:"structured_output_schema"`
-	Version                int                          `json:"version"`
+	ID                         uuid.UUID                    `bun:",pk,type:uuid" json:"id"`
+	Name                       string                       `json:"name"`
+	Provider                   LLMProvider                  `json:"provider"`
+	Model                      string                       `json:"model"`
+	BaseURL                    string                       `json:"base_url"`
+	ReasoningEffort            runtimetypes.ReasoningEffort `json:"reasoning_effort"`
+	Instructions               string                       `json:"instructions"`
+	AutoCompact                bool                         `json:"auto_compact"`
+	CompactAtPercent           int                          `json:"compact_at_percent"`
+	CompactionPrompt           string                       `json:"compaction_prompt"`
+	ShellAccess                bool                         `json:"shell_access"`
+	WebSearch                  bool                         `json:"web_search"`
+	StructuredOutput           bool                         `json:"structured_output"`
+	StructuredOutputSchema     map[string]any               `bun:"type:jsonb,nullzero" json:"structured_output_schema"`
+	StructuredOutputRepairs    int                          `json:"structured_output_repairs"`
+	FallbackModels             []ModelRef                   `bun:"type:jsonb,nullzero" json:"fallback_models"`
+	RecoveryPolicy             RecoveryPolicy               `json:"recovery_policy"`
+	HistoryMode                HistoryMode                  `json:"history_mode"`
+	MaxConcurrentConversations int                          `json:"max_concurrent_conversations"`
+	MaxCostCents               int64                        `json:"max_cost_cents"`
+	MaxTotalTokens             int64                        `json:"max_total_tokens"`
+	MaxSteps                   int                          `json:"max_steps"`
+	MaxParallelToolCalls       int                          `json:"max_parallel_tool_calls"`
+	SequentialTools            []string                     `bun:"type:jsonb,nullzero" json:"sequential_tools"`
+	MaxConsecutiveToolFailures int                          `json:"max_consecutive_tool_failures"`
+	LoopWindow                 int                          `json:"loop_window"`
+	LoopThreshold              int                          `json:"loop_threshold"`
+	LoopAction                 LoopAction                   `json:"loop_action"`
+	Version                    int                          `json:"version"`
 }
 
 // ---- Constructor ----
 
-func NewAgentSpec(name string, prov LLMProvider, model, instructions string, reasoningEffort runtimetypes.ReasoningEffort, version int) (*Spec, error) {
+func NewAgentSpec(name string, prov LLMProvider, model, baseURL, instructions string, reasoningEffort runtimetypes.ReasoningEffort, version int) (*Spec, error) {
 	const op = "agent.NewAgentSpec"
 
 	id, err := uuid.NewV7()
@@ -49,20 +66,28 @@ func NewAgentSpec(name string, prov LLMProvider, model, instructions string, rea
 	}
 
 	pt := &Spec{
-		ID:                     id,
-		Name:                   strings.TrimSpace(name),
-		Provider:               prov,
-		Model:                  strings.TrimSpace(model),
-		Instructions:           strings.TrimSpace(instructions),
-		AutoCompact:            false,
-		CompactAtPercent:       90,
-		CompactionPrompt:       "",
-		ShellAccess:            true,
-		WebSearch:              false,
-		StructuredOutput:       false,
-		StructuredOutputSchema: nil,
-		ReasoningEffort:        reasoningEffort,
-		Version:                version,
+		ID:                         id,
+		Name:                       strings.TrimSpace(name),
+		Provider:                   prov,
+		Model:                      strings.TrimSpace(model),
+		BaseURL:                    strings.TrimSpace(baseURL),
+		Instructions:               strings.TrimSpace(instructions),
+		AutoCompact:                false,
+		CompactAtPercent:           90,
+		CompactionPrompt:           "",
+		ShellAccess:                true,
+		WebSearch:                  false,
+		StructuredOutput:           false,
+		StructuredOutputSchema:     nil,
+		StructuredOutputRepairs:    2,
+		MaxConsecutiveToolFailures: 5,
+		LoopWindow:                 20,
+		LoopThreshold:              3,
+		LoopAction:                 LoopActionWarn,
+		RecoveryPolicy:             RecoveryPolicyFail,
+		HistoryMode:                HistoryModeServerSide,
+		ReasoningEffort:            reasoningEffort,
+		Version:                    version,
 	}
 
 	err = pt.Validate()
@@ -94,10 +119,82 @@ func (pt *Spec) Validate() error {
 		return ez.Wrap(op, err)
 	}
 
+	if pt.Provider == LLMProviderOpenAICompatible && pt.BaseURL == "" {
+		return ez.New(op, ez.EINVALID, "base_url is required for open_ai_compatible providers", nil)
+	}
+
+	if pt.BaseURL != "" {
+		parsed, err := url.Parse(pt.BaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ez.New(op, ez.EINVALID, "base_url must be an absolute http(s) URL", err)
+		}
+	}
+
 	if pt.CompactAtPercent <= 0 || pt.CompactAtPercent > 100 {
 		return ez.New(op, ez.EINVALID, "compact_at_percent must be between 1 and 100", nil)
 	}
 
+	if pt.MaxConcurrentConversations < 0 {
+		return ez.New(op, ez.EINVALID, "max_concurrent_conversations must be >= 0", nil)
+	}
+
+	if pt.MaxCostCents < 0 {
+		return ez.New(op, ez.EINVALID, "max_cost_cents must be >= 0", nil)
+	}
+
+	if pt.MaxTotalTokens < 0 {
+		return ez.New(op, ez.EINVALID, "max_total_tokens must be >= 0", nil)
+	}
+
+	if pt.MaxSteps < 0 {
+		return ez.New(op, ez.EINVALID, "max_steps must be >= 0", nil)
+	}
+
+	if pt.MaxParallelToolCalls < 0 {
+		return ez.New(op, ez.EINVALID, "max_parallel_tool_calls must be >= 0", nil)
+	}
+
+	if pt.MaxConsecutiveToolFailures < 0 {
+		return ez.New(op, ez.EINVALID, "max_consecutive_tool_failures must be >= 0", nil)
+	}
+
+	if pt.LoopWindow < 0 {
+		return ez.New(op, ez.EINVALID, "loop_window must be >= 0", nil)
+	}
+
+	if pt.LoopThreshold < 0 || pt.LoopThreshold == 1 {
+		return ez.New(op, ez.EINVALID, "loop_threshold must be 0 or at least 2", nil)
+	}
+
+	if pt.StructuredOutputRepairs < 0 {
+		return ez.New(op, ez.EINVALID, "structured_output_repairs must be >= 0", nil)
+	}
+
+	if pt.StructuredOutput {
+		_, err := runtimetypes.CompileSchema(pt.StructuredOutputSchema)
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
+	if err := pt.LoopAction.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if err := pt.RecoveryPolicy.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if err := pt.HistoryMode.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	for _, fallback := range pt.FallbackModels {
+		if err := fallback.Validate(); err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
 	return nil
 }
 
@@ -179,6 +276,28 @@ func GetAgentSpecByID(ctx context.Context, db bun.IDB, id uuid.UUID) (*Spec, err
 	return pt, nil
 }
 
+// SpecUsesBaseURL reports whether a spec calls the provider at baseURL, with its
+// model or one of its fallback models.
+func SpecUsesBaseURL(ctx context.Context, db bun.IDB, provider LLMProvider, baseURL string) (bool, error) {
+	const op = "agent.SpecUsesBaseURL"
+
+	// Matches the fallback models with that provider and base URL, whatever their model
+	fallback, err := json.Marshal([]map[string]string{{"provider": string(provider), "base_url": baseURL}})
+	if err != nil {
+		return false, ez.Wrap(op, err)
+	}
+
+	exists, err := db.NewSelect().
+		Model((*Spec)(nil)).
+		Where("(provider = ? AND base_url = ?) OR fallback_models @> ?::jsonb", provider, baseURL, string(fallback)).
+		Exists(ctx)
+	if err != nil {
+		return false, ez.Wrap(op, err)
+	}
+
+	return exists, nil
+}
+
 // ---- Pagination helpers ----
 
 func (pt Spec) GetCursor() string {
@@ -0,0 +1,30 @@
+package agent
+
+import "github.com/vanclief/compose/primitives/enums"
+
+// StepKind tells what a conversation step record is about.
+type StepKind string
+
+const (
+	// StepKindLLMCall records a call to the model.
+	StepKindLLMCall StepKind = "llm_call"
+	// StepKindToolCall records the execution of a tool call.
+	StepKindToolCall StepKind = "tool_call"
+)
+
+var stepKindSet = enums.Set([]StepKind{
+	StepKindLLMCall,
+	StepKindToolCall,
+})
+
+func (k StepKind) Validate() error {
+	return enums.Validate(k, stepKindSet)
+}
+
+func (k StepKind) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(k, stepKindSet)
+}
+
+func (k *StepKind) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, k, stepKindSet)
+}
@@ -12,6 +12,9 @@ var REGISTRABLE = []interface{}{}
 var ALL = []interface{}{
 	(*hook.Hook)(nil),
 	(*agent.Conversation)(nil),
+	(*agent.ConversationJob)(nil),
+	(*agent.ConversationMessage)(nil),
+	(*agent.ConversationStep)(nil),
 	(*agent.Spec)(nil),
 	(*user.User)(nil),
 }
@@ -12,6 +12,8 @@ const (
 	EventTypePostContextCompaction EventType = "post_context_compaction"
 	EventTypePreToolUse            EventType = "pre_tool_use"
 	EventTypePostToolUse           EventType = "post_tool_use"
+	EventTypeBudgetExceeded        EventType = "budget_exceeded"
+	EventTypeLoopDetected          EventType = "loop_detected"
 )
 
 var evenTypeSet = enums.Set([]EventType{
@@ -22,6 +24,8 @@ var evenTypeSet = enums.Set([]EventType{
 	EventTypePostContextCompaction,
 	EventTypePreToolUse,
 	EventTypePostToolUse,
+	EventTypeBudgetExceeded,
+	EventTypeLoopDetected,
 })
 
 func (e EventType) Validate() error {
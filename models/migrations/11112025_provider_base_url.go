@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN base_url TEXT NOT NULL DEFAULT '';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN base_url TEXT NOT NULL DEFAULT '';
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN base_url;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN base_url;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
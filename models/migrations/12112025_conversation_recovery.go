@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN recovery_policy TEXT NOT NULL DEFAULT 'fail';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN recovery_policy TEXT NOT NULL DEFAULT 'fail',
+			ADD COLUMN status_reason TEXT NOT NULL DEFAULT '',
+			ADD COLUMN heartbeat_at TIMESTAMPTZ;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idx_conversations_active_status
+			ON conversations (status)
+			WHERE status IN ('queued', 'running');
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			DROP INDEX IF EXISTS idx_conversations_active_status;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN heartbeat_at,
+			DROP COLUMN status_reason,
+			DROP COLUMN recovery_policy;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN recovery_policy;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS conversation_jobs (
+				id UUID PRIMARY KEY,
+				conversation_id UUID NOT NULL,
+				status VARCHAR NOT NULL,
+				priority BIGINT NOT NULL DEFAULT 0,
+				attempts BIGINT NOT NULL DEFAULT 0,
+				max_attempts BIGINT NOT NULL,
+				run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				locked_by VARCHAR,
+				lease_expires_at TIMESTAMPTZ,
+				cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+				last_error VARCHAR,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				finished_at TIMESTAMPTZ
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idx_conversation_jobs_pending
+			ON conversation_jobs (priority DESC, run_at ASC, id ASC)
+			WHERE status = 'pending';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idx_conversation_jobs_lease
+			ON conversation_jobs (lease_expires_at)
+			WHERE status = 'running';
+		`)
+		if err != nil {
+			return err
+		}
+
+		// A conversation can only have one queued or running job at a time
+		_, err = db.ExecContext(ctx, `
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_conversation_jobs_active
+			ON conversation_jobs (conversation_id)
+			WHERE status IN ('pending', 'running');
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			DROP TABLE IF EXISTS conversation_jobs;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
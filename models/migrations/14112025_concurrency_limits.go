@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN max_concurrent_conversations BIGINT NOT NULL DEFAULT 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversation_jobs
+			ADD COLUMN IF NOT EXISTS agent_spec_id UUID,
+			ADD COLUMN IF NOT EXISTS session_id VARCHAR NOT NULL DEFAULT '';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE conversation_jobs AS job
+			SET agent_spec_id = conversation.agent_spec_id,
+				session_id = COALESCE(conversation.session_id, '')
+			FROM conversations AS conversation
+			WHERE conversation.id = job.conversation_id;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idx_conversation_jobs_running_spec
+			ON conversation_jobs (agent_spec_id)
+			WHERE status = 'running';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idx_conversation_jobs_running_session
+			ON conversation_jobs (session_id)
+			WHERE status = 'running';
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			DROP INDEX IF EXISTS idx_conversation_jobs_running_session;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			DROP INDEX IF EXISTS idx_conversation_jobs_running_spec;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversation_jobs
+			DROP COLUMN session_id,
+			DROP COLUMN agent_spec_id;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN max_concurrent_conversations;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN max_cost_cents BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN max_total_tokens BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN max_steps BIGINT NOT NULL DEFAULT 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN max_cost_cents BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN max_total_tokens BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN max_steps BIGINT NOT NULL DEFAULT 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN max_steps,
+			DROP COLUMN max_total_tokens,
+			DROP COLUMN max_cost_cents;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN max_steps,
+			DROP COLUMN max_total_tokens,
+			DROP COLUMN max_cost_cents;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
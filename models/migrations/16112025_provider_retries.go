@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN provider_retries BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN provider_error_class TEXT NOT NULL DEFAULT '';
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN provider_error_class,
+			DROP COLUMN provider_retries;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN fallback_models JSONB;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN fallback_models JSONB,
+			ADD COLUMN step_usage JSONB;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN step_usage,
+			DROP COLUMN fallback_models;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN fallback_models;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
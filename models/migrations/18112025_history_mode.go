@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN history_mode TEXT NOT NULL DEFAULT 'server_side';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN history_mode TEXT NOT NULL DEFAULT 'server_side',
+			ADD COLUMN last_response_id TEXT NOT NULL DEFAULT '',
+			ADD COLUMN last_response_messages BIGINT NOT NULL DEFAULT 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN last_response_messages,
+			DROP COLUMN last_response_id,
+			DROP COLUMN history_mode;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN history_mode;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
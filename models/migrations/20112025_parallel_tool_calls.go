@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN max_parallel_tool_calls INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN sequential_tools JSONB;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN max_parallel_tool_calls INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN sequential_tools JSONB;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN sequential_tools,
+			DROP COLUMN max_parallel_tool_calls;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN sequential_tools,
+			DROP COLUMN max_parallel_tool_calls;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
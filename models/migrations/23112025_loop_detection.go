@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			ADD COLUMN loop_window INTEGER NOT NULL DEFAULT 20,
+			ADD COLUMN loop_threshold INTEGER NOT NULL DEFAULT 3,
+			ADD COLUMN loop_action TEXT NOT NULL DEFAULT 'warn';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN loop_window INTEGER NOT NULL DEFAULT 20,
+			ADD COLUMN loop_threshold INTEGER NOT NULL DEFAULT 3,
+			ADD COLUMN loop_action TEXT NOT NULL DEFAULT 'warn';
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN loop_action,
+			DROP COLUMN loop_threshold,
+			DROP COLUMN loop_window;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE agent_specs
+			DROP COLUMN loop_action,
+			DROP COLUMN loop_threshold,
+			DROP COLUMN loop_window;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS conversation_steps (
+				id UUID PRIMARY KEY,
+				conversation_id UUID NOT NULL,
+				step BIGINT NOT NULL,
+				kind VARCHAR NOT NULL,
+				provider VARCHAR,
+				model VARCHAR,
+				estimated_input_tokens BIGINT NOT NULL DEFAULT 0,
+				input_tokens BIGINT NOT NULL DEFAULT 0,
+				output_tokens BIGINT NOT NULL DEFAULT 0,
+				cached_tokens BIGINT NOT NULL DEFAULT 0,
+				cost BIGINT NOT NULL DEFAULT 0,
+				tool_name VARCHAR,
+				tool_call_id VARCHAR,
+				exit_code BIGINT,
+				tool_error VARCHAR,
+				hooks JSONB,
+				error VARCHAR,
+				started_at TIMESTAMPTZ NOT NULL,
+				finished_at TIMESTAMPTZ NOT NULL,
+				latency_ms BIGINT NOT NULL DEFAULT 0
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idx_conversation_steps_conversation
+			ON conversation_steps (conversation_id, id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			DROP TABLE IF EXISTS conversation_steps;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
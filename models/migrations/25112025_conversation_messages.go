@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS conversation_messages (
+				conversation_id UUID NOT NULL,
+				seq BIGINT NOT NULL,
+				role VARCHAR NOT NULL,
+				message JSONB NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				PRIMARY KEY (conversation_id, seq)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idx_conversation_messages_role
+			ON conversation_messages (conversation_id, role, seq);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// Databases created after this change never had the column
+		var hasMessages bool
+		err = db.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'conversations' AND column_name = 'messages'
+			);
+		`).Scan(&hasMessages)
+		if err != nil {
+			return err
+		}
+
+		if !hasMessages {
+			return nil
+		}
+
+		// Move the existing transcripts, keeping their order
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO conversation_messages (conversation_id, seq, role, message, created_at)
+			SELECT c.id, m.seq - 1, COALESCE(m.message->>'Role', ''), m.message, COALESCE(c.created_at, NOW())
+			FROM conversations AS c,
+			LATERAL jsonb_array_elements(c.messages) WITH ORDINALITY AS m(message, seq)
+			WHERE jsonb_typeof(c.messages) = 'array';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN messages;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN messages JSONB;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE conversations AS c
+			SET messages = (
+				SELECT jsonb_agg(m.message ORDER BY m.seq)
+				FROM conversation_messages AS m
+				WHERE m.conversation_id = c.id
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			DROP TABLE IF EXISTS conversation_messages;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Costs are stored unrounded so calls under half a cent add up
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ALTER COLUMN cost TYPE DOUBLE PRECISION;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversation_steps
+			ALTER COLUMN cost TYPE DOUBLE PRECISION;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversation_steps
+			ALTER COLUMN cost TYPE BIGINT USING ROUND(cost);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ALTER COLUMN cost TYPE INTEGER USING ROUND(cost);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Databases created after this change never had the column
+		var hasStepUsage bool
+		err := db.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'conversations' AND column_name = 'step_usage'
+			);
+		`).Scan(&hasStepUsage)
+		if err != nil {
+			return err
+		}
+
+		if !hasStepUsage {
+			return nil
+		}
+
+		// Conversations that ran before conversation_steps only have their usage in the
+		// column, it becomes their LLM call steps with time ordered IDs
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO conversation_steps (id, conversation_id, step, kind, provider, model, input_tokens, output_tokens, cached_tokens, cost, started_at, finished_at)
+			SELECT
+				(lpad(to_hex((extract(epoch FROM u.created_at) * 1000)::BIGINT), 12, '0') || '7' || substr(md5(random()::TEXT), 1, 3) || '8' || substr(md5(random()::TEXT), 1, 15))::UUID,
+				u.conversation_id, u.step, 'llm_call', u.provider, u.model, u.input_tokens, u.output_tokens, u.cached_tokens, u.cost, u.created_at, u.created_at
+			FROM (
+				SELECT
+					c.id AS conversation_id,
+					COALESCE((s.usage->>'step')::BIGINT, 0) AS step,
+					s.usage->>'provider' AS provider,
+					s.usage->>'model' AS model,
+					COALESCE((s.usage->>'input_tokens')::BIGINT, 0) AS input_tokens,
+					COALESCE((s.usage->>'output_tokens')::BIGINT, 0) AS output_tokens,
+					COALESCE((s.usage->>'cached_tokens')::BIGINT, 0) AS cached_tokens,
+					COALESCE((s.usage->>'cost')::DOUBLE PRECISION, 0) AS cost,
+					COALESCE((s.usage->>'created_at')::TIMESTAMPTZ, c.created_at, NOW()) AS created_at
+				FROM conversations AS c,
+				LATERAL jsonb_array_elements(c.step_usage) AS s(usage)
+				WHERE jsonb_typeof(c.step_usage) = 'array'
+				AND NOT EXISTS (
+					SELECT 1 FROM conversation_steps AS cs
+					WHERE cs.conversation_id = c.id AND cs.kind = 'llm_call'
+				)
+			) AS u;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN step_usage;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN step_usage JSONB;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE conversations AS c
+			SET step_usage = (
+				SELECT jsonb_agg(jsonb_build_object(
+					'step', s.step,
+					'provider', s.provider,
+					'model', s.model,
+					'input_tokens', s.input_tokens,
+					'output_tokens', s.output_tokens,
+					'cached_tokens', s.cached_tokens,
+					'cost', s.cost,
+					'created_at', s.finished_at
+				) ORDER BY s.id)
+				FROM conversation_steps AS s
+				WHERE s.conversation_id = c.id AND s.kind = 'llm_call'
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
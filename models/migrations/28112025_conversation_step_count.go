@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			ADD COLUMN IF NOT EXISTS step_count BIGINT NOT NULL DEFAULT 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		// Every inference step made at least one model call
+		_, err = db.ExecContext(ctx, `
+			UPDATE conversations AS c
+			SET step_count = s.calls
+			FROM (
+				SELECT conversation_id, COUNT(*) AS calls
+				FROM conversation_steps
+				WHERE kind = 'llm_call'
+				GROUP BY conversation_id
+			) AS s
+			WHERE s.conversation_id = c.id;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE conversations
+			DROP COLUMN step_count;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
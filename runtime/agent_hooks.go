@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	shellmcp "github.com/vanclief/agent-composer/mcp/shell"
+	"github.com/vanclief/agent-composer/models/agent"
 	"github.com/vanclief/agent-composer/models/hook"
 	types "github.com/vanclief/agent-composer/runtime/types"
 	"github.com/vanclief/ez"
@@ -46,26 +47,34 @@ func (ci *ConversationInstance) RunConversationEndedHook(ctx context.Context) er
 	return nil
 }
 
-func (ci *ConversationInstance) RunPreToolUseHook(ctx context.Context, toolCall *types.ToolCall, toolCallResponse string) error {
+// RunPreToolUseHook runs the pre_tool_use hooks of a call, adding their outcomes to
+// its step record. When a hook blocks the call it returns the tool result to record
+// instead along with the error.
+func (ci *ConversationInstance) RunPreToolUseHook(ctx context.Context, toolCall *types.ToolCall, toolCallResponse string, step *agent.ConversationStep) (string, error) {
 	for _, h := range ci.hooks[hook.EventTypePreToolUse] {
-		_, err := ci.runToolHooks(ctx, h, toolCall, toolCallResponse)
+		out, blocked, err := ci.runToolHooks(ctx, h, toolCall, toolCallResponse)
+		recordHook(step, h, out, err)
 		if err != nil {
-			return err
+			return blocked, err
 		}
 	}
 
-	return nil
+	return "", nil
 }
 
-func (ci *ConversationInstance) RunPostToolUseHook(ctx context.Context, toolCall *types.ToolCall, toolCallResponse string) error {
+// RunPostToolUseHook runs the post_tool_use hooks of a call, adding their outcomes
+// to its step record. When a hook blocks the call it returns the tool result to
+// record instead along with the error.
+func (ci *ConversationInstance) RunPostToolUseHook(ctx context.Context, toolCall *types.ToolCall, toolCallResponse string, step *agent.ConversationStep) (string, error) {
 	for _, h := range ci.hooks[hook.EventTypePostToolUse] {
-		_, err := ci.runToolHooks(ctx, h, toolCall, toolCallResponse)
+		out, blocked, err := ci.runToolHooks(ctx, h, toolCall, toolCallResponse)
+		recordHook(step, h, out, err)
 		if err != nil {
-			return err
+			return blocked, err
 		}
 	}
 
-	return nil
+	return "", nil
 }
 
 func (ci *ConversationInstance) RunPreContextCompactionHook(ctx context.Context, compactedConversationID uuid.UUID) error {
@@ -90,6 +99,30 @@ func (ci *ConversationInstance) RunPostContextCompactionHook(ctx context.Context
 	return nil
 }
 
+func (ci *ConversationInstance) RunBudgetExceededHook(ctx context.Context, reason string) error {
+	for _, h := range ci.hooks[hook.EventTypeBudgetExceeded] {
+		_, err := ci.runBudgetHooks(ctx, h, reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunLoopDetectedHook escalates a loop found by the loop detector. Hooks exiting
+// with code 2 send their stderr to the model.
+func (ci *ConversationInstance) RunLoopDetectedHook(ctx context.Context, loop string) error {
+	for _, h := range ci.hooks[hook.EventTypeLoopDetected] {
+		_, err := ci.runLoopHooks(ctx, h, loop)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type ConversationStateHook struct {
 	ID             string         `json:"id"`
 	ConversationID string         `json:"conversation_id"`
@@ -116,6 +149,8 @@ func (ci *ConversationInstance) runConversationHooks(ctx context.Context, h hook
 	payload, _ := json.Marshal(e)
 
 	out, err := RunHook(ctx, h, payload)
+	ci.emitHook(h, out, err)
+
 	if out.ExitCode == 2 {
 		stderrText := strings.TrimSpace(string(out.Stderr))
 		if stderrText == "" {
@@ -140,7 +175,10 @@ type ToolUseHook struct {
 	ToolResponse   string         `json:"tool_response,omitempty"`
 }
 
-func (ci *ConversationInstance) runToolHooks(ctx context.Context, h hook.Hook, toolCall *types.ToolCall, toolCallResponse string) (HookResult, error) {
+// runToolHooks runs a tool hook, returning the tool result that replaces the call's
+// when the hook blocks it. Tool calls of a step run concurrently, so it must not
+// change the transcript.
+func (ci *ConversationInstance) runToolHooks(ctx context.Context, h hook.Hook, toolCall *types.ToolCall, toolCallResponse string) (HookResult, string, error) {
 	var lastResponse string
 	lam, found := ci.LatestAssistantMessage()
 	if found {
@@ -148,7 +186,7 @@ func (ci *ConversationInstance) runToolHooks(ctx context.Context, h hook.Hook, t
 	}
 
 	if toolCall == nil {
-		return HookResult{}, ez.New("runToolHooks", ez.EINVALID, "toolCall cannot be nil", nil)
+		return HookResult{}, "", ez.New("runToolHooks", ez.EINVALID, "toolCall cannot be nil", nil)
 	}
 
 	e := ToolUseHook{
@@ -165,6 +203,8 @@ func (ci *ConversationInstance) runToolHooks(ctx context.Context, h hook.Hook, t
 	payload, _ := json.Marshal(e)
 
 	out, err := RunHook(ctx, h, payload)
+	ci.emitHook(h, out, err)
+
 	if out.ExitCode == 2 {
 		stderrText := strings.TrimSpace(string(out.Stderr))
 		if stderrText == "" {
@@ -177,18 +217,19 @@ func (ci *ConversationInstance) runToolHooks(ctx context.Context, h hook.Hook, t
 			Command:  toolCall.CommandString(),
 		}
 
+		blocked := stderrText
+
 		encoded, marshalErr := json.Marshal(payload)
 		if marshalErr != nil {
 			log.Error().Err(marshalErr).Msg("Failed to marshal hook error payload")
-			ci.AddToolMessage(toolCall.Name, toolCall.CallID, stderrText)
 		} else {
-			ci.AddToolMessage(toolCall.Name, toolCall.CallID, string(encoded))
+			blocked = string(encoded)
 		}
 
-		return out, err // Return on first exit code 2
+		return out, blocked, err // Return on first exit code 2
 	}
 
-	return out, nil
+	return out, "", nil
 }
 
 type CompactionHook struct {
@@ -219,6 +260,8 @@ func (ci *ConversationInstance) runCompactionHooks(ctx context.Context, h hook.H
 	payload, _ := json.Marshal(e)
 
 	out, err := RunHook(ctx, h, payload)
+	ci.emitHook(h, out, err)
+
 	if out.ExitCode == 2 {
 		stderrText := strings.TrimSpace(string(out.Stderr))
 		if stderrText == "" {
@@ -231,3 +274,97 @@ func (ci *ConversationInstance) runCompactionHooks(ctx context.Context, h hook.H
 
 	return out, nil
 }
+
+type LoopHook struct {
+	ID             string         `json:"id"`
+	ConversationID string         `json:"conversation_id"`
+	EventType      hook.EventType `json:"event_type"`
+	AgentName      string         `json:"agent_name"`
+	LastResponse   string         `json:"last_response,omitempty"`
+	Loop           string         `json:"loop"`
+	Step           int            `json:"step"`
+}
+
+func (ci *ConversationInstance) runLoopHooks(ctx context.Context, h hook.Hook, loop string) (HookResult, error) {
+	var lastResponse string
+	lam, found := ci.LatestAssistantMessage()
+	if found {
+		lastResponse = lam.Content
+	}
+
+	e := LoopHook{
+		ID:             h.ID.String(),
+		ConversationID: ci.ID.String(),
+		AgentName:      ci.AgentName,
+		EventType:      h.EventType,
+		LastResponse:   lastResponse,
+		Loop:           loop,
+		Step:           ci.step,
+	}
+
+	payload, _ := json.Marshal(e)
+
+	out, err := RunHook(ctx, h, payload)
+	ci.emitHook(h, out, err)
+
+	if out.ExitCode == 2 {
+		stderrText := strings.TrimSpace(string(out.Stderr))
+		if stderrText == "" {
+			stderrText = "hook failed"
+		}
+
+		ci.AddMessage(types.MessageRoleUser, stderrText)
+		return out, err // Return on first exit code 2
+	}
+
+	return out, nil
+}
+
+type BudgetHook struct {
+	ID             string         `json:"id"`
+	ConversationID string         `json:"conversation_id"`
+	EventType      hook.EventType `json:"event_type"`
+	AgentName      string         `json:"agent_name"`
+	LastResponse   string         `json:"last_response,omitempty"`
+	Reason         string         `json:"reason"`
+	InputTokens    int64          `json:"input_tokens"`
+	OutputTokens   int64          `json:"output_tokens"`
+	CachedTokens   int64          `json:"cached_tokens"`
+	Cost           float64        `json:"cost"`
+	MaxCostCents   int64          `json:"max_cost_cents,omitempty"`
+	MaxTotalTokens int64          `json:"max_total_tokens,omitempty"`
+	MaxSteps       int            `json:"max_steps"`
+}
+
+// runBudgetHooks notifies a hook that the run was halted. The run is already over,
+// so unlike the other hooks these can't block it or add messages.
+func (ci *ConversationInstance) runBudgetHooks(ctx context.Context, h hook.Hook, reason string) (HookResult, error) {
+	var lastResponse string
+	lam, found := ci.LatestAssistantMessage()
+	if found {
+		lastResponse = lam.Content
+	}
+
+	e := BudgetHook{
+		ID:             h.ID.String(),
+		ConversationID: ci.ID.String(),
+		AgentName:      ci.AgentName,
+		EventType:      h.EventType,
+		LastResponse:   lastResponse,
+		Reason:         reason,
+		InputTokens:    ci.InputTokens,
+		OutputTokens:   ci.OutputTokens,
+		CachedTokens:   ci.CachedTokens,
+		Cost:           ci.Cost,
+		MaxCostCents:   ci.MaxCostCents,
+		MaxTotalTokens: ci.MaxTotalTokens,
+		MaxSteps:       ci.StepLimit(),
+	}
+
+	payload, _ := json.Marshal(e)
+
+	out, err := RunHook(ctx, h, payload)
+	ci.emitHook(h, out, err)
+
+	return out, err
+}
@@ -13,11 +13,24 @@ type ConversationInstance struct {
 	provider types.LLMProvider
 	mcpMux   *mcp.Mux
 	hooks    map[hook.EventType][]hook.Hook
+	publish  func(event ConversationEvent)
+	step     int
+	priority int
+	// The entry of the fallback chain serving the run, see fallBack
+	model      agent.ModelRef
+	modelIndex int
+	// Compiled StructuredOutputSchema and repair turns taken, see checkStructuredOutput
+	outputSchema *types.Schema
+	repairs      int
+	// Tool calls that failed in a row, see runToolCalls
+	toolFailures int
+	// Watches the tool calls of the run, nil when detection is off
+	loops LoopDetector
 }
 
 func (ci *ConversationInstance) LatestAssistantMessage() (*types.Message, bool) {
 	for i := len(ci.Messages) - 1; i >= 0; i-- {
-		if ci.Messages[i].Role == types.MessageRoleAssistant && ci.Messages[i].ToolCall == nil {
+		if ci.Messages[i].Role == types.MessageRoleAssistant && ci.Messages[i].ToolCall == nil && !ci.Messages[i].IsReasoning() {
 			return &ci.Messages[i], true
 		}
 	}
@@ -41,14 +54,37 @@ func (ci *ConversationInstance) AddMessage(role types.MessageRole, content strin
 	}
 
 	ci.Messages = append(ci.Messages, msg)
+	ci.emitLastMessage()
 }
 
 func (ci *ConversationInstance) AddToolMessage(toolName, toolCallID, content string) {
 	msg := *types.NewToolMessage(toolName, toolCallID, content)
 	ci.Messages = append(ci.Messages, msg)
+	ci.emitLastMessage()
 }
 
 func (ci *ConversationInstance) AddAssistantToolCall(toolCall types.ToolCall) {
 	msg := *types.NewAssistantToolCallMessage(toolCall)
 	ci.Messages = append(ci.Messages, msg)
+	ci.emitLastMessage()
+}
+
+func (ci *ConversationInstance) AddAssistantThinking(thinking types.Thinking) {
+	msg := *types.NewAssistantThinkingMessage(thinking)
+	ci.Messages = append(ci.Messages, msg)
+	ci.emitLastMessage()
+}
+
+func (ci *ConversationInstance) AddAssistantReasoningSummary(summary types.ReasoningSummary) {
+	msg := *types.NewAssistantReasoningSummaryMessage(summary)
+	ci.Messages = append(ci.Messages, msg)
+	ci.emitLastMessage()
+}
+
+// AddAssistantAnswer records the final answer with the web sources it cites.
+func (ci *ConversationInstance) AddAssistantAnswer(content string, citations []types.Citation) {
+	msg := *types.NewAssistantMessage(content)
+	msg.Citations = citations
+	ci.Messages = append(ci.Messages, msg)
+	ci.emitLastMessage()
 }
@@ -7,15 +7,16 @@ import (
 	"github.com/vanclief/agent-composer/mcp"
 	shellmcp "github.com/vanclief/agent-composer/mcp/shell"
 	"github.com/vanclief/agent-composer/models/agent"
-	"github.com/vanclief/agent-composer/runtime/providers/chatgpt"
 	types "github.com/vanclief/agent-composer/runtime/types"
 	"github.com/vanclief/ez"
 )
 
 // TODO: Try to take out the Runtime
 
-func (rt *Runtime) NewConversationInstanceFromSpec(ctx context.Context, agentSpecID uuid.UUID, sessionID string) (*ConversationInstance, error) {
-	const op = "runtime.NewConversationInstanceFromSpec"
+// NewConversationFromSpec creates a conversation from the agent spec. It runs once
+// it is enqueued, see EnqueueConversation.
+func (rt *Runtime) NewConversationFromSpec(ctx context.Context, agentSpecID uuid.UUID, sessionID string) (*agent.Conversation, error) {
+	const op = "runtime.NewConversationFromSpec"
 
 	// Step 1) Fetch the agent spec
 	spec, err := agent.GetAgentSpecByID(ctx, rt.db, agentSpecID)
@@ -33,7 +34,12 @@ func (rt *Runtime) NewConversationInstanceFromSpec(ctx context.Context, agentSpe
 
 	conversation.SessionID = sessionID
 
-	return rt.newAgentInstance(ctx, conversation, true)
+	err = conversation.Insert(ctx, rt.db)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return conversation, nil
 }
 
 func (rt *Runtime) NewConversationInstance(ctx context.Context, conversationID uuid.UUID) (*ConversationInstance, error) {
@@ -45,14 +51,14 @@ func (rt *Runtime) NewConversationInstance(ctx context.Context, conversationID u
 		return nil, ez.Wrap(op, err)
 	}
 
-	return rt.newAgentInstance(ctx, conversation, false)
+	return rt.newAgentInstance(ctx, conversation)
 }
 
-func (rt *Runtime) newAgentInstance(ctx context.Context, conversation *agent.Conversation, new bool) (*ConversationInstance, error) {
+func (rt *Runtime) newAgentInstance(ctx context.Context, conversation *agent.Conversation) (*ConversationInstance, error) {
 	const op = "runtime.NewAgentInstance"
 
-	// Step 1) Create the ChatGPT instance
-	chatGPT, err := chatgpt.New(rt.openai)
+	// Step 1) Create the LLM provider instance, falling back when the primary one is not available
+	modelIndex, provider, err := rt.firstAvailableModel(modelCandidates(conversation))
 	if err != nil {
 		return nil, ez.Wrap(op, err)
 	}
@@ -83,16 +89,9 @@ func (rt *Runtime) newAgentInstance(ctx context.Context, conversation *agent.Con
 
 	conversation.Tools = tools
 
-	if new {
-		err = conversation.Insert(ctx, rt.db)
-		if err != nil {
-			return nil, ez.Wrap(op, err)
-		}
-	} else {
-		err = conversation.Update(ctx, rt.db)
-		if err != nil {
-			return nil, ez.Wrap(op, err)
-		}
+	err = conversation.UpdateTools(ctx, rt.db)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
 	}
 
 	// Step 6) Load the hooks
@@ -105,10 +104,13 @@ func (rt *Runtime) newAgentInstance(ctx context.Context, conversation *agent.Con
 
 	ci := &ConversationInstance{
 		Conversation: conversation,
-		provider:     chatGPT,
 		mcpMux:       mux,
 		hooks:        hooks,
+		publish:      rt.publishConversationEvent,
+		loops:        rt.newLoopDetector(conversation),
 	}
 
+	rt.useModel(ci, modelIndex, provider)
+
 	return ci, nil
 }
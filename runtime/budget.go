@@ -0,0 +1,34 @@
+package runtime
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/ez"
+)
+
+// haltOverBudget stops the run with the budget_exceeded status and lets the hooks
+// know. The transcript is left as is so the conversation can be resumed.
+func (rt *Runtime) haltOverBudget(ctx context.Context, ci *ConversationInstance, reason string) error {
+	const op = "runtime.haltOverBudget"
+
+	log.Warn().
+		Str("Name", ci.AgentName).
+		Str("ID", ci.ID.String()).
+		Str("reason", reason).
+		Int("step", ci.step).
+		Msg("Agent halted over budget")
+
+	ci.Status = agent.ConversationStatusBudgetExceeded
+	ci.StatusReason = reason
+
+	err := ci.Update(ctx, rt.db)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	ci.RunBudgetExceededHook(ctx, reason)
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// cancelWaitTimeout bounds how long CancelConversation waits for the run to wind
+// down and persist its final state. Runs held by another process stop on their
+// next lease renewal, so it must be longer than heartbeatInterval.
+const cancelWaitTimeout = 3 * heartbeatInterval
+
+// cancelPollInterval is how often CancelConversation checks on runs held by
+// another process.
+const cancelPollInterval = 500 * time.Millisecond
+
+// canceledToolResult is recorded for tool calls that never got a result because the
+// conversation was canceled, so the transcript stays valid for providers on resume.
+const canceledToolResult = `{"error":"canceled","message":"Conversation was canceled before the tool call finished."}`
+
+// runningJob tracks a conversation executing in this process.
+type runningJob struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// trackJob registers a running conversation so it can be canceled. The returned
+// function must be called when the job finishes.
+func (rt *Runtime) trackJob(conversationID uuid.UUID, cancel context.CancelCauseFunc) func() {
+	job := &runningJob{cancel: cancel, done: make(chan struct{})}
+
+	rt.jobsMu.Lock()
+	rt.jobs[conversationID] = job
+	rt.jobsMu.Unlock()
+
+	return func() {
+		rt.jobsMu.Lock()
+		if rt.jobs[conversationID] == job {
+			delete(rt.jobs, conversationID)
+		}
+		rt.jobsMu.Unlock()
+
+		close(job.done)
+	}
+}
+
+// CancelConversation stops a queued or running conversation. Canceling the run
+// aborts the in-flight provider call and kills any running shell process group;
+// the worker then persists the partial transcript with status canceled. Runs held
+// by another process are flagged and stop on their next lease renewal.
+func (rt *Runtime) CancelConversation(ctx context.Context, conversationID uuid.UUID) (*agent.Conversation, error) {
+	const op = "runtime.CancelConversation"
+
+	// Step 1) Make sure the conversation can be canceled
+	conversation, err := agent.GetConversationByID(ctx, rt.db, conversationID)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	if conversation.Status.IsTerminal() {
+		errMsg := fmt.Sprintf("conversation with ID %s is already %s", conversationID, conversation.Status)
+		return nil, ez.New(op, ez.ECONFLICT, errMsg, nil)
+	}
+
+	// Step 2) If this process is running it, cancel it and wait for it to persist its state
+	rt.jobsMu.Lock()
+	running, ok := rt.jobs[conversationID]
+	rt.jobsMu.Unlock()
+
+	if ok {
+		running.cancel(errConversationCanceled)
+
+		select {
+		case <-running.done:
+		case <-time.After(cancelWaitTimeout):
+			return nil, ez.New(op, ez.EUNAVAILABLE, "timed out waiting for the conversation to stop", nil)
+		case <-ctx.Done():
+			return nil, ez.Wrap(op, ctx.Err())
+		}
+
+		conversation, err = agent.GetConversationByID(ctx, rt.db, conversationID)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+
+		return conversation, nil
+	}
+
+	// Step 3) Otherwise go through its job
+	job, err := agent.GetActiveConversationJob(ctx, rt.db, conversationID)
+	if err != nil && ez.ErrorCode(err) != ez.ENOTFOUND {
+		return nil, ez.Wrap(op, err)
+	}
+
+	if job != nil {
+		canceled, err := job.CancelPending(ctx, rt.db)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+
+		// A worker claimed it, ask it to stop
+		if !canceled {
+			err = job.RequestCancel(ctx, rt.db)
+			if err != nil {
+				return nil, ez.Wrap(op, err)
+			}
+
+			conversation, err = rt.waitForTerminal(ctx, conversationID)
+			if err != nil {
+				return nil, ez.Wrap(op, err)
+			}
+
+			return conversation, nil
+		}
+	}
+
+	// Step 4) Nothing is executing it, so just record the cancellation
+	conversation.Status = agent.ConversationStatusCanceled
+	conversation.Messages = closeDanglingToolCalls(conversation.Messages, canceledToolResult)
+
+	err = conversation.Update(ctx, rt.db)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	rt.publishConversationEvent(ConversationEvent{
+		ConversationID: conversation.ID,
+		Type:           ConversationEventStatus,
+		Status:         conversation.Status,
+	})
+
+	return conversation, nil
+}
+
+// waitForTerminal polls the conversation until it stops or cancelWaitTimeout
+// elapses, returning its latest state either way.
+func (rt *Runtime) waitForTerminal(ctx context.Context, conversationID uuid.UUID) (*agent.Conversation, error) {
+	const op = "runtime.waitForTerminal"
+
+	deadline := time.After(cancelWaitTimeout)
+
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		conversation, err := agent.GetConversationByID(ctx, rt.db, conversationID)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+
+		if conversation.Status.IsTerminal() {
+			return conversation, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ez.Wrap(op, ctx.Err())
+		case <-deadline:
+			return conversation, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeDanglingToolCalls appends the given result for every tool call that has none.
+func closeDanglingToolCalls(messages []types.Message, result string) []types.Message {
+	answered := make(map[string]bool)
+	for _, msg := range messages {
+		if msg.Role == types.MessageRoleTool {
+			answered[msg.ToolCallID] = true
+		}
+	}
+
+	for _, msg := range messages {
+		if msg.ToolCall == nil || answered[msg.ToolCall.CallID] {
+			continue
+		}
+
+		messages = append(messages, *types.NewToolMessage(msg.ToolCall.Name, msg.ToolCall.CallID, result))
+		answered[msg.ToolCall.CallID] = true
+	}
+
+	return messages
+}
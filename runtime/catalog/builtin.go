@@ -0,0 +1,250 @@
+package catalog
+
+import (
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime/types"
+)
+
+// Standard tier list prices, checked against the provider pricing pages. Override
+// them through the catalog file when they change.
+
+var (
+	// Capability sets shared by most models
+	reasoningModel = Capabilities{Reasoning: true, Tools: true, StructuredOutput: true, Vision: true}
+	chatModel      = Capabilities{Tools: true, StructuredOutput: true, Vision: true}
+	audioModel     = Capabilities{Tools: true}
+
+	webSearch    = map[string]float64{types.ToolCallWebSearch: 10}
+	webSearchGPT = map[string]float64{types.ToolCallWebSearch: 25} // Non-reasoning models, search content tokens included
+)
+
+var builtin = []Model{
+	// Anthropic
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-opus-4-5",
+		Pricing:       Pricing{Input: 5, CachedInput: 0.5, CacheWrite: 6.25, Output: 25, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-opus-4-1",
+		Pricing:       Pricing{Input: 15, CachedInput: 1.5, CacheWrite: 18.75, Output: 75, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 32000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-opus-4", Aliases: []string{"claude-opus-4-0", "claude-4-opus"},
+		Pricing:       Pricing{Input: 15, CachedInput: 1.5, CacheWrite: 18.75, Output: 75, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 32000, Capabilities: Capabilities{Reasoning: true, Tools: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-sonnet-4-5",
+		Pricing:       Pricing{Input: 3, CachedInput: 0.3, CacheWrite: 3.75, Output: 15, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-sonnet-4", Aliases: []string{"claude-sonnet-4-0", "claude-4-sonnet"},
+		Pricing:       Pricing{Input: 3, CachedInput: 0.3, CacheWrite: 3.75, Output: 15, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: Capabilities{Reasoning: true, Tools: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-haiku-4-5",
+		Pricing:       Pricing{Input: 1, CachedInput: 0.1, CacheWrite: 1.25, Output: 5, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-3-7-sonnet",
+		Pricing:       Pricing{Input: 3, CachedInput: 0.3, CacheWrite: 3.75, Output: 15, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: Capabilities{Reasoning: true, Tools: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-3-5-haiku",
+		Pricing:       Pricing{Input: 0.8, CachedInput: 0.08, CacheWrite: 1, Output: 4, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 8192, Capabilities: Capabilities{Tools: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderAnthropic, ID: "claude-3-haiku",
+		Pricing:       Pricing{Input: 0.25, CachedInput: 0.03, CacheWrite: 0.3, Output: 1.25},
+		ContextWindow: 200000, MaxOutputTokens: 4096, Capabilities: Capabilities{Tools: true, Vision: true},
+	},
+
+	// OpenAI
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-5", Aliases: []string{"gpt-5-auto"},
+		Pricing:       Pricing{Input: 1.25, CachedInput: 0.125, Output: 10, ToolCalls: webSearch},
+		ContextWindow: 400000, MaxOutputTokens: 128000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-5-mini",
+		Pricing:       Pricing{Input: 0.25, CachedInput: 0.025, Output: 2, ToolCalls: webSearch},
+		ContextWindow: 400000, MaxOutputTokens: 128000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-5-nano",
+		Pricing:       Pricing{Input: 0.05, CachedInput: 0.005, Output: 0.4, ToolCalls: webSearch},
+		ContextWindow: 400000, MaxOutputTokens: 128000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-5-chat-latest",
+		Pricing:       Pricing{Input: 1.25, CachedInput: 0.125, Output: 10},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: Capabilities{Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-5-codex", Aliases: []string{"gpt-5-code", "gpt-5-coder"},
+		Pricing:       Pricing{Input: 1.25, CachedInput: 0.125, Output: 10},
+		ContextWindow: 400000, MaxOutputTokens: 128000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-5-pro",
+		Pricing:       Pricing{Input: 15, Output: 120, ToolCalls: webSearch},
+		ContextWindow: 400000, MaxOutputTokens: 272000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-5-search-api",
+		Pricing:       Pricing{Input: 1.25, CachedInput: 0.125, Output: 10},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: Capabilities{Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4.1",
+		Pricing:       Pricing{Input: 2, CachedInput: 0.5, Output: 8, ToolCalls: webSearchGPT},
+		ContextWindow: 1047576, MaxOutputTokens: 32768, Capabilities: chatModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4.1-mini",
+		Pricing:       Pricing{Input: 0.4, CachedInput: 0.1, Output: 1.6, ToolCalls: webSearchGPT},
+		ContextWindow: 1047576, MaxOutputTokens: 32768, Capabilities: chatModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4.1-nano",
+		Pricing:       Pricing{Input: 0.1, CachedInput: 0.025, Output: 0.4},
+		ContextWindow: 1047576, MaxOutputTokens: 32768, Capabilities: chatModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o",
+		Pricing:       Pricing{Input: 2.5, CachedInput: 1.25, Output: 10, ToolCalls: webSearchGPT},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: chatModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-2024-05-13",
+		Pricing:       Pricing{Input: 5, Output: 15},
+		ContextWindow: 128000, MaxOutputTokens: 4096, Capabilities: Capabilities{Tools: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-mini",
+		Pricing:       Pricing{Input: 0.15, CachedInput: 0.075, Output: 0.6, ToolCalls: webSearchGPT},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: chatModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-search-preview",
+		Pricing:       Pricing{Input: 2.5, Output: 10},
+		ContextWindow: 128000, MaxOutputTokens: 16384,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-mini-search-preview",
+		Pricing:       Pricing{Input: 0.15, Output: 0.6},
+		ContextWindow: 128000, MaxOutputTokens: 16384,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-realtime",
+		Pricing:       Pricing{Input: 4, CachedInput: 0.4, Output: 16},
+		ContextWindow: 32000, MaxOutputTokens: 4096, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-realtime-mini",
+		Pricing:       Pricing{Input: 0.6, CachedInput: 0.06, Output: 2.4},
+		ContextWindow: 32000, MaxOutputTokens: 4096, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-realtime-preview",
+		Pricing:       Pricing{Input: 5, CachedInput: 2.5, Output: 20},
+		ContextWindow: 32000, MaxOutputTokens: 4096, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-mini-realtime-preview",
+		Pricing:       Pricing{Input: 0.6, CachedInput: 0.3, Output: 2.4},
+		ContextWindow: 16000, MaxOutputTokens: 4096, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-audio",
+		Pricing:       Pricing{Input: 2.5, Output: 10},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-audio-mini",
+		Pricing:       Pricing{Input: 0.6, Output: 2.4},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-audio-preview",
+		Pricing:       Pricing{Input: 2.5, Output: 10},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-4o-mini-audio-preview",
+		Pricing:       Pricing{Input: 0.15, Output: 0.6},
+		ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: audioModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o1",
+		Pricing:       Pricing{Input: 15, CachedInput: 7.5, Output: 60},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o1-pro",
+		Pricing:       Pricing{Input: 150, Output: 600},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o1-mini",
+		Pricing:       Pricing{Input: 1.1, CachedInput: 0.55, Output: 4.4},
+		ContextWindow: 128000, MaxOutputTokens: 65536, Capabilities: Capabilities{Reasoning: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o3",
+		Pricing:       Pricing{Input: 2, CachedInput: 0.5, Output: 8, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o3-pro",
+		Pricing:       Pricing{Input: 20, Output: 80, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o3-mini",
+		Pricing:       Pricing{Input: 1.1, CachedInput: 0.55, Output: 4.4},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: Capabilities{Reasoning: true, Tools: true, StructuredOutput: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o3-deep-research",
+		Pricing:       Pricing{Input: 10, CachedInput: 2.5, Output: 40, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: Capabilities{Reasoning: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o4-mini",
+		Pricing:       Pricing{Input: 1.1, CachedInput: 0.275, Output: 4.4, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "o4-mini-deep-research",
+		Pricing:       Pricing{Input: 2, CachedInput: 0.5, Output: 8, ToolCalls: webSearch},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: Capabilities{Reasoning: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "codex-mini-latest",
+		Pricing:       Pricing{Input: 1.5, CachedInput: 0.375, Output: 6},
+		ContextWindow: 200000, MaxOutputTokens: 100000, Capabilities: reasoningModel,
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "computer-use-preview",
+		Pricing:       Pricing{Input: 3, Output: 12},
+		ContextWindow: 8192, MaxOutputTokens: 1024, Capabilities: Capabilities{Tools: true, Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-image-1",
+		Pricing:      Pricing{Input: 5, CachedInput: 1.25},
+		Capabilities: Capabilities{Vision: true},
+	},
+	{
+		Provider: agent.LLMProviderOpenAI, ID: "gpt-image-1-mini",
+		Pricing:      Pricing{Input: 2, CachedInput: 0.2},
+		Capabilities: Capabilities{Vision: true},
+	},
+}
@@ -0,0 +1,351 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// FileName is the catalog file read from the config directory.
+const FileName = "models.json"
+
+// Model describes what a model costs and what it can do.
+type Model struct {
+	Provider        agent.LLMProvider `json:"provider"`
+	ID              string            `json:"id"`
+	Aliases         []string          `json:"aliases,omitempty"`
+	Pricing         Pricing           `json:"pricing"`
+	ContextWindow   int               `json:"context_window,omitempty"`    // In tokens, 0 = unknown
+	MaxOutputTokens int64             `json:"max_output_tokens,omitempty"` // 0 = unknown
+	Capabilities    Capabilities      `json:"capabilities"`
+}
+
+// Pricing is in USD per million tokens, tool fees in USD per thousand calls.
+// A cached input or cache write price of 0 bills those tokens as regular input.
+type Pricing struct {
+	Input       float64            `json:"input"`
+	CachedInput float64            `json:"cached_input,omitempty"`
+	CacheWrite  float64            `json:"cache_write,omitempty"`
+	Output      float64            `json:"output"`
+	ToolCalls   map[string]float64 `json:"tool_calls,omitempty"`
+}
+
+type Capabilities struct {
+	Reasoning        bool `json:"reasoning"`
+	Tools            bool `json:"tools"`
+	StructuredOutput bool `json:"structured_output"`
+	Vision           bool `json:"vision"`
+}
+
+func (m Model) Validate() error {
+	const op = "catalog.Model.Validate"
+
+	if err := m.Provider.Validate(); err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if strings.TrimSpace(m.ID) == "" {
+		return ez.New(op, ez.EINVALID, "id is required", nil)
+	}
+
+	if m.ContextWindow < 0 || m.MaxOutputTokens < 0 {
+		return ez.New(op, ez.EINVALID, "context_window and max_output_tokens must be >= 0", nil)
+	}
+
+	prices := []float64{m.Pricing.Input, m.Pricing.CachedInput, m.Pricing.CacheWrite, m.Pricing.Output}
+	for _, fee := range m.Pricing.ToolCalls {
+		prices = append(prices, fee)
+	}
+
+	for _, price := range prices {
+		if price < 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+			errMsg := fmt.Sprintf("prices of model %s must be >= 0", m.ID)
+			return ez.New(op, ez.EINVALID, errMsg, nil)
+		}
+	}
+
+	return nil
+}
+
+// Cost returns the USD cents of a provider call, unrounded so the costs of many
+// small calls add up. InputTokens is the whole prompt, cache reads and writes included.
+func (m Model) Cost(usage types.TokenUsage) float64 {
+	cachedPrice := m.Pricing.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = m.Pricing.Input
+	}
+
+	cacheWritePrice := m.Pricing.CacheWrite
+	if cacheWritePrice == 0 {
+		cacheWritePrice = m.Pricing.Input
+	}
+
+	inputTokens := max(usage.InputTokens-usage.CacheReadInputTokens-usage.CacheWriteInputTokens, 0)
+
+	// Prices are per 1M tokens in USD, i.e. per 10K tokens in cents
+	cents := (float64(inputTokens)*m.Pricing.Input +
+		float64(usage.CacheReadInputTokens)*cachedPrice +
+		float64(usage.CacheWriteInputTokens)*cacheWritePrice +
+		float64(usage.OutputTokens)*m.Pricing.Output) / 10_000
+
+	// Tool fees are per 1K calls in USD, i.e. per 10 calls in cents
+	for tool, calls := range usage.ToolCalls {
+		cents += float64(calls) * m.Pricing.ToolCalls[tool] / 10
+	}
+
+	return cents
+}
+
+// Catalog holds the models known to the runtime. It starts with the builtin
+// entries and can be extended or overridden from the config directory and at
+// runtime.
+type Catalog struct {
+	mu      sync.RWMutex
+	models  map[string]Model  // By provider/id
+	aliases map[string]string // provider/alias => provider/id
+	missing sync.Map          // Unknown models that have been logged
+}
+
+// New returns a catalog with the builtin models.
+func New() *Catalog {
+	c := &Catalog{
+		models:  make(map[string]Model),
+		aliases: make(map[string]string),
+	}
+
+	for _, model := range builtin {
+		c.set(model)
+	}
+
+	return c
+}
+
+// Load returns the builtin catalog with the models of the file at path on top of
+// it. Entries replace the builtin model with the same provider and id. A missing
+// file is not an error.
+func Load(path string) (*Catalog, error) {
+	const op = "catalog.Load"
+
+	c := New()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		errMsg := fmt.Sprintf("failed to read model catalog %s", path)
+		return nil, ez.New(op, ez.EINTERNAL, errMsg, err)
+	}
+
+	var file struct {
+		Models []Model `json:"models"`
+	}
+
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		errMsg := fmt.Sprintf("model catalog %s is not valid JSON", path)
+		return nil, ez.New(op, ez.EINVALID, errMsg, err)
+	}
+
+	for _, model := range file.Models {
+		err = c.Set(model)
+		if err != nil {
+			return nil, ez.Wrap(op, err)
+		}
+	}
+
+	log.Info().Str("path", path).Int("models", len(file.Models)).Msg("Loaded model catalog")
+
+	return c, nil
+}
+
+// Set adds a model to the catalog or replaces the one with the same provider and id.
+func (c *Catalog) Set(model Model) error {
+	const op = "catalog.Catalog.Set"
+
+	err := model.Validate()
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(model)
+
+	return nil
+}
+
+func (c *Catalog) set(model Model) {
+	model.ID = strings.ToLower(strings.TrimSpace(model.ID))
+	id := key(model.Provider, model.ID)
+
+	// Drop the aliases of the entry being replaced
+	for alias, target := range c.aliases {
+		if target == id {
+			delete(c.aliases, alias)
+		}
+	}
+
+	for _, alias := range model.Aliases {
+		c.aliases[key(model.Provider, alias)] = id
+	}
+
+	c.models[id] = model
+	c.missing.Delete(id)
+}
+
+// Remove drops a model from the catalog, reporting whether it was there.
+func (c *Catalog) Remove(provider agent.LLMProvider, id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id = key(provider, id)
+
+	_, ok := c.models[id]
+	if !ok {
+		return false
+	}
+
+	for alias, target := range c.aliases {
+		if target == id {
+			delete(c.aliases, alias)
+		}
+	}
+
+	delete(c.models, id)
+
+	return true
+}
+
+// List returns every model sorted by provider and id.
+func (c *Catalog) List() []Model {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	models := make([]Model, 0, len(c.models))
+	for _, model := range c.models {
+		models = append(models, model)
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].Provider != models[j].Provider {
+			return models[i].Provider < models[j].Provider
+		}
+		return models[i].ID < models[j].ID
+	})
+
+	return models
+}
+
+var (
+	dateSuffix    = regexp.MustCompile(`-\d{8}$`)
+	isoDateSuffix = regexp.MustCompile(`-\d{4}-\d{2}-\d{2}$`)
+)
+
+// Lookup finds a model by id or alias. Dated snapshots and -latest ids fall back
+// to their model family, e.g. "claude-sonnet-4-5-20250929" to "claude-sonnet-4-5".
+func (c *Catalog) Lookup(provider agent.LLMProvider, model string) (Model, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	candidates := []string{
+		model,
+		strings.TrimSuffix(model, "-latest"),
+		dateSuffix.ReplaceAllString(model, ""),
+		isoDateSuffix.ReplaceAllString(model, ""),
+	}
+
+	for _, candidate := range candidates {
+		id := key(provider, candidate)
+
+		if entry, ok := c.models[id]; ok {
+			return entry, true
+		}
+
+		if target, ok := c.aliases[id]; ok {
+			return c.models[target], true
+		}
+	}
+
+	return Model{}, false
+}
+
+// Provider returns the view of the catalog used by a provider implementation.
+func (c *Catalog) Provider(provider agent.LLMProvider) *ProviderModels {
+	return &ProviderModels{catalog: c, provider: provider}
+}
+
+// ProviderModels is the part of the catalog that belongs to a single provider.
+type ProviderModels struct {
+	catalog  *Catalog
+	provider agent.LLMProvider
+}
+
+// Lookup finds a model of the provider, logging the first lookup of each model
+// that is not in the catalog.
+func (p *ProviderModels) Lookup(model string) (Model, bool) {
+	if p == nil || p.catalog == nil {
+		return Model{}, false
+	}
+
+	entry, ok := p.catalog.Lookup(p.provider, model)
+	if ok {
+		return entry, true
+	}
+
+	// Unknown models are neither priced nor checked for compaction
+	_, logged := p.catalog.missing.LoadOrStore(key(p.provider, model), true)
+	if !logged {
+		log.Warn().
+			Str("provider", string(p.provider)).
+			Str("model", model).
+			Msg("Model is not in the catalog, its cost is 0 and its context window is not checked")
+	}
+
+	return Model{}, false
+}
+
+// Cost prices a provider call, unknown models cost 0.
+func (p *ProviderModels) Cost(model string, usage types.TokenUsage) float64 {
+	entry, ok := p.Lookup(model)
+	if !ok {
+		return 0
+	}
+
+	return entry.Cost(usage)
+}
+
+// CheckContextWindow fails when the input takes more than compactAtPercent of the
+// model's context window. Models with an unknown window always pass.
+func (p *ProviderModels) CheckContextWindow(model string, totalInputTokens, compactAtPercent int) error {
+	const op = "catalog.ProviderModels.CheckContextWindow"
+
+	entry, ok := p.Lookup(model)
+	if !ok || entry.ContextWindow <= 0 {
+		return nil
+	}
+
+	if totalInputTokens > entry.ContextWindow*compactAtPercent/100 {
+		errMsg := fmt.Sprintf("Input tokens %d exceed context window %d for model %s", totalInputTokens, entry.ContextWindow, model)
+		return ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	return nil
+}
+
+func key(provider agent.LLMProvider, model string) string {
+	return string(provider) + "/" + strings.ToLower(strings.TrimSpace(model))
+}
@@ -0,0 +1,253 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// eventsChannel is the Postgres channel conversation events are relayed on, so
+// subscribers get the events of runs executed by other processes.
+const eventsChannel = "agent_composer_conversation_events"
+
+// maxNotifyPayload keeps relayed events under the 8000 bytes Postgres allows for a
+// notification payload.
+const maxNotifyPayload = 7900
+
+// relayedEvent is the payload of a relayed event. The origin process already
+// delivered the event to its own subscribers and skips it when it comes back.
+type relayedEvent struct {
+	Origin string            `json:"origin"`
+	Event  ConversationEvent `json:"event"`
+}
+
+// relayRetryDelay is how long RelayEvents waits before listening again, and sendEvents
+// before notifying again, after Postgres failed.
+const relayRetryDelay = time.Second
+
+// relayNotifyAttempts is how many times an event other than a delta is notified
+// before it is given up.
+const relayNotifyAttempts = 5
+
+// maxRelayedDelta bounds the text of the deltas merged into one relayed event, so
+// the event stays under maxNotifyPayload once encoded.
+const maxRelayedDelta = 2048
+
+// eventRelay queues the events published by this process until they are notified.
+// Consecutive deltas are merged into one event and are the only events dropped
+// when the relay falls behind; status, usage, compaction and the other events are
+// always kept, terminal statuses end SSE streams.
+type eventRelay struct {
+	mu     sync.Mutex
+	queue  []ConversationEvent
+	queued chan struct{} // Signals sendEvents that the queue has events
+}
+
+func newEventRelay() *eventRelay {
+	return &eventRelay{queued: make(chan struct{}, 1)}
+}
+
+// push adds an event to the queue, merging it into the previous one when both are
+// deltas of the same output.
+func (relay *eventRelay) push(event ConversationEvent) {
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+
+	if isDelta(event.Type) {
+		last := len(relay.queue) - 1
+		if last >= 0 && sameDelta(relay.queue[last], event) && len(relay.queue[last].Delta)+len(event.Delta) <= maxRelayedDelta {
+			relay.queue[last].Delta += event.Delta
+			return
+		}
+
+		if len(relay.queue) >= eventBufferSize {
+			log.Warn().
+				Str("conversation_id", event.ConversationID.String()).
+				Str("type", string(event.Type)).
+				Msg("Dropping relayed conversation delta, the relay is behind")
+			return
+		}
+	}
+
+	relay.queue = append(relay.queue, event)
+
+	select {
+	case relay.queued <- struct{}{}:
+	default:
+	}
+}
+
+// take empties the queue, returning the events in the order they were published.
+func (relay *eventRelay) take() []ConversationEvent {
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+
+	events := relay.queue
+	relay.queue = nil
+
+	return events
+}
+
+// isDelta reports whether events of the type stream a response as it is generated.
+func isDelta(eventType ConversationEventType) bool {
+	switch eventType {
+	case ConversationEventTextDelta, ConversationEventReasoningDelta, ConversationEventToolCallDelta:
+		return true
+	}
+
+	return false
+}
+
+// sameDelta reports whether two deltas continue the same output.
+func sameDelta(a, b ConversationEvent) bool {
+	return a.Type == b.Type &&
+		a.ConversationID == b.ConversationID &&
+		a.Step == b.Step &&
+		a.ToolCallID == b.ToolCallID &&
+		a.ToolName == b.ToolName
+}
+
+// RelayEvents shares the conversation events with every process using the same
+// database through Postgres LISTEN/NOTIFY, blocking until ctx is canceled. Without
+// it subscribers only get the events of runs executed by this process.
+func (rt *Runtime) RelayEvents(ctx context.Context) {
+	listener := pgdriver.NewListener(rt.db.DB)
+	defer listener.Close()
+
+	// Events are only relayed once other processes can relay theirs to us, the
+	// listener keeps the channel and listens again when it reconnects
+	for {
+		err := listener.Listen(ctx, eventsChannel)
+		if err == nil {
+			break
+		}
+
+		log.Error().Err(err).Msg("Failed to listen for conversation events")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relayRetryDelay):
+		}
+	}
+
+	rt.relaying.Store(true)
+	defer rt.relaying.Store(false)
+
+	go rt.sendEvents(ctx)
+
+	notifications := listener.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification, ok := <-notifications:
+			if !ok {
+				return
+			}
+
+			if notification.Channel == eventsChannel {
+				rt.receiveEvent(notification.Payload)
+			}
+		}
+	}
+}
+
+// relayEvent queues an event published by this process for the other processes.
+func (rt *Runtime) relayEvent(event ConversationEvent) {
+	if !rt.relaying.Load() {
+		return
+	}
+
+	rt.relay.push(event)
+}
+
+// sendEvents notifies the queued events in the order they were published.
+func (rt *Runtime) sendEvents(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rt.relay.queued:
+		}
+
+		for _, event := range rt.relay.take() {
+			payload, ok := rt.eventPayload(event)
+			if !ok {
+				continue
+			}
+
+			rt.notifyEvent(ctx, event, payload)
+		}
+	}
+}
+
+// notifyEvent sends a relayed event, retrying the events other than deltas while
+// Postgres is unavailable.
+func (rt *Runtime) notifyEvent(ctx context.Context, event ConversationEvent, payload string) {
+	attempts := relayNotifyAttempts
+	if isDelta(event.Type) {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := pgdriver.Notify(ctx, rt.db.DB, eventsChannel, payload)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		if attempt >= attempts {
+			log.Error().Err(err).Str("conversation_id", event.ConversationID.String()).Str("type", string(event.Type)).Msg("Failed to relay conversation event")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relayRetryDelay):
+		}
+	}
+}
+
+// eventPayload encodes an event for a notification. Messages too large for it are
+// left out, subscribers get them from the persisted transcript by MessageIndex.
+func (rt *Runtime) eventPayload(event ConversationEvent) (string, bool) {
+	payload, err := json.Marshal(relayedEvent{Origin: rt.workerID, Event: event})
+	if err == nil && len(payload) > maxNotifyPayload && event.Message != nil {
+		event.Message = nil
+		payload, err = json.Marshal(relayedEvent{Origin: rt.workerID, Event: event})
+	}
+
+	if err != nil || len(payload) > maxNotifyPayload {
+		log.Warn().
+			Err(err).
+			Str("conversation_id", event.ConversationID.String()).
+			Str("type", string(event.Type)).
+			Msg("Dropping conversation event that can't be relayed")
+		return "", false
+	}
+
+	return string(payload), true
+}
+
+// receiveEvent delivers an event relayed by another process to the local subscribers.
+func (rt *Runtime) receiveEvent(payload string) {
+	var relayed relayedEvent
+
+	err := json.Unmarshal([]byte(payload), &relayed)
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring malformed relayed conversation event")
+		return
+	}
+
+	if relayed.Origin == rt.workerID {
+		return
+	}
+
+	rt.events.deliver(relayed.Event)
+}
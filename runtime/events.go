@@ -0,0 +1,254 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/models/hook"
+	types "github.com/vanclief/agent-composer/runtime/types"
+)
+
+// ConversationEventType identifies the kind of ConversationEvent.
+type ConversationEventType string
+
+const (
+	// Streamed while the provider generates a response
+	ConversationEventTextDelta      ConversationEventType = "text_delta"
+	ConversationEventReasoningDelta ConversationEventType = "reasoning_delta"
+	ConversationEventToolCallDelta  ConversationEventType = "tool_call_delta"
+
+	// Emitted as the conversation progresses
+	ConversationEventStatus     ConversationEventType = "status"
+	ConversationEventMessage    ConversationEventType = "message"
+	ConversationEventToolCall   ConversationEventType = "tool_call"
+	ConversationEventToolResult ConversationEventType = "tool_result"
+	ConversationEventHook       ConversationEventType = "hook"
+	ConversationEventUsage      ConversationEventType = "usage"
+	ConversationEventCompaction ConversationEventType = "compaction"
+	ConversationEventRetry      ConversationEventType = "retry"
+	ConversationEventFallback   ConversationEventType = "fallback"
+)
+
+// eventBufferSize is how many events a slow subscriber can fall behind before
+// events are dropped for it.
+const eventBufferSize = 512
+
+// ConversationEvent is a live update about a running conversation. Only the
+// fields relevant to the event type are set.
+type ConversationEvent struct {
+	ConversationID uuid.UUID             `json:"conversation_id"`
+	Type           ConversationEventType `json:"type"`
+	Step           int                   `json:"step"`
+	CreatedAt      time.Time             `json:"created_at"`
+
+	// Deltas
+	Delta      string `json:"delta,omitempty"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	ToolName   string `json:"tool_name,omitempty"`
+
+	// Status changes
+	Status agent.ConversationStatus `json:"status,omitempty"`
+
+	// Messages, tool calls and tool results, with their position in the transcript
+	MessageIndex int            `json:"message_index,omitempty"`
+	Message      *types.Message `json:"message,omitempty"`
+
+	Hook                    *HookOutcome   `json:"hook,omitempty"`
+	Usage                   *UsageUpdate   `json:"usage,omitempty"`
+	CompactedConversationID *uuid.UUID     `json:"compacted_conversation_id,omitempty"`
+	Retry                   *ProviderRetry `json:"retry,omitempty"`
+	Fallback                *ModelFallback `json:"fallback,omitempty"`
+}
+
+// HookOutcome describes the result of running a single hook.
+type HookOutcome struct {
+	HookID    uuid.UUID      `json:"hook_id"`
+	EventType hook.EventType `json:"event_type"`
+	ExitCode  int            `json:"exit_code"`
+	Blocked   bool           `json:"blocked"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// UsageUpdate carries the conversation totals after a provider call.
+type UsageUpdate struct {
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CachedTokens int64   `json:"cached_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// eventBroker fans out conversation events to the subscribers of each conversation.
+type eventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[chan ConversationEvent]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[uuid.UUID]map[chan ConversationEvent]struct{})}
+}
+
+// SubscribeConversation returns a channel with the live events of a conversation and
+// a function to stop receiving them. Events are dropped, not queued, for subscribers
+// that don't keep up. The channel is closed when unsubscribing.
+func (rt *Runtime) SubscribeConversation(conversationID uuid.UUID) (<-chan ConversationEvent, func()) {
+	broker := rt.events
+
+	ch := make(chan ConversationEvent, eventBufferSize)
+
+	broker.mu.Lock()
+	subs, ok := broker.subscribers[conversationID]
+	if !ok {
+		subs = make(map[chan ConversationEvent]struct{})
+		broker.subscribers[conversationID] = subs
+	}
+	subs[ch] = struct{}{}
+	broker.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			broker.mu.Lock()
+			defer broker.mu.Unlock()
+
+			delete(broker.subscribers[conversationID], ch)
+			if len(broker.subscribers[conversationID]) == 0 {
+				delete(broker.subscribers, conversationID)
+			}
+
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// publishConversationEvent delivers an event to the local subscribers and relays it
+// to the other processes, see RelayEvents.
+func (rt *Runtime) publishConversationEvent(event ConversationEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	rt.events.deliver(event)
+	rt.relayEvent(event)
+}
+
+// deliver fans out an event to the subscribers of its conversation.
+func (broker *eventBroker) deliver(event ConversationEvent) {
+	broker.mu.RLock()
+	defer broker.mu.RUnlock()
+
+	for ch := range broker.subscribers[event.ConversationID] {
+		select {
+		case ch <- event:
+		default:
+			log.Warn().
+				Str("conversation_id", event.ConversationID.String()).
+				Str("type", string(event.Type)).
+				Msg("Dropping conversation event for slow subscriber")
+		}
+	}
+}
+
+// emit publishes an event for the conversation, filling in the ID and current step.
+func (ci *ConversationInstance) emit(event ConversationEvent) {
+	if ci.publish == nil {
+		return
+	}
+
+	event.ConversationID = ci.ID
+	event.Step = ci.step
+
+	ci.publish(event)
+}
+
+func (ci *ConversationInstance) emitStatus() {
+	ci.emit(ConversationEvent{Type: ConversationEventStatus, Status: ci.Status})
+}
+
+func (ci *ConversationInstance) emitUsage() {
+	ci.emit(ConversationEvent{
+		Type: ConversationEventUsage,
+		Usage: &UsageUpdate{
+			InputTokens:  ci.InputTokens,
+			OutputTokens: ci.OutputTokens,
+			CachedTokens: ci.CachedTokens,
+			Cost:         ci.Cost,
+		},
+	})
+}
+
+// emitLastMessage publishes the message that was just appended to the transcript.
+func (ci *ConversationInstance) emitLastMessage() {
+	index := len(ci.Messages) - 1
+	msg := ci.Messages[index]
+
+	eventType := ConversationEventMessage
+	switch {
+	case msg.ToolCall != nil:
+		eventType = ConversationEventToolCall
+	case msg.Role == types.MessageRoleTool:
+		eventType = ConversationEventToolResult
+	}
+
+	ci.emit(ConversationEvent{Type: eventType, MessageIndex: index, Message: &msg})
+}
+
+func (ci *ConversationInstance) emitHook(h hook.Hook, result HookResult, err error) {
+	outcome := &HookOutcome{
+		HookID:    h.ID,
+		EventType: h.EventType,
+		ExitCode:  result.ExitCode,
+		Blocked:   result.ExitCode == 2,
+	}
+
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+
+	ci.emit(ConversationEvent{Type: ConversationEventHook, Hook: outcome})
+}
+
+func (ci *ConversationInstance) emitCompaction(compactedConversationID uuid.UUID) {
+	ci.emit(ConversationEvent{Type: ConversationEventCompaction, CompactedConversationID: &compactedConversationID})
+}
+
+// streamHandler forwards provider stream events to the conversation subscribers.
+func (ci *ConversationInstance) streamHandler() types.StreamHandler {
+	return func(event types.StreamEvent) {
+		var eventType ConversationEventType
+
+		switch event.Type {
+		case types.StreamEventTextDelta:
+			eventType = ConversationEventTextDelta
+		case types.StreamEventReasoningDelta:
+			eventType = ConversationEventReasoningDelta
+		case types.StreamEventToolCallDelta:
+			eventType = ConversationEventToolCallDelta
+		default:
+			return
+		}
+
+		ci.emit(ConversationEvent{
+			Type:       eventType,
+			Delta:      event.Delta,
+			ToolCallID: event.ToolCallID,
+			ToolName:   event.ToolName,
+		})
+	}
+}
+
+// chat calls the provider for a single step, streaming the output to the conversation
+// subscribers when the provider supports it.
+func (rt *Runtime) chat(ctx context.Context, ci *ConversationInstance, request *types.ChatRequest) (types.ChatResponse, error) {
+	streamer, ok := ci.provider.(types.StreamingLLMProvider)
+	if !ok {
+		return ci.provider.Chat(ctx, ci.model.Model, request)
+	}
+
+	return streamer.ChatStream(ctx, ci.model.Model, request, ci.streamHandler())
+}
@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// ModelFallback describes a switch to the next model of the fallback chain.
+type ModelFallback struct {
+	From   agent.ModelRef `json:"from"`
+	To     agent.ModelRef `json:"to"`
+	Reason string         `json:"reason"`
+}
+
+// modelCandidates returns the primary model of the conversation followed by its fallbacks.
+func modelCandidates(conversation *agent.Conversation) []agent.ModelRef {
+	primary := agent.ModelRef{
+		Provider: conversation.Provider,
+		Model:    conversation.Model,
+		BaseURL:  conversation.BaseURL,
+	}
+
+	return append([]agent.ModelRef{primary}, conversation.FallbackModels...)
+}
+
+// firstAvailableModel creates the provider of the first candidate that can be used,
+// e.g. skipping providers without credentials. The error of the primary model is
+// returned when none can.
+func (rt *Runtime) firstAvailableModel(candidates []agent.ModelRef) (int, types.LLMProvider, error) {
+	const op = "runtime.firstAvailableModel"
+
+	var firstErr error
+
+	for i, candidate := range candidates {
+		provider, err := rt.newProvider(candidate.Provider, candidate.BaseURL)
+		if err == nil {
+			return i, provider, nil
+		}
+
+		log.Warn().Err(err).Str("model", candidate.String()).Msg("Model is not available")
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return 0, nil, ez.Wrap(op, firstErr)
+}
+
+// useModel makes the candidate at index the model serving the conversation.
+func (rt *Runtime) useModel(ci *ConversationInstance, index int, provider types.LLMProvider) {
+	ci.modelIndex = index
+	ci.model = modelCandidates(ci.Conversation)[index]
+	ci.provider = rt.withRetries(ci, ci.model, provider)
+}
+
+// fallBack switches the conversation to the next model of its fallback chain that
+// is available. With checkContext the model must also fit the conversation in its
+// context window. The switch lasts for the rest of the run.
+func (rt *Runtime) fallBack(ctx context.Context, ci *ConversationInstance, checkContext bool, reason string) bool {
+	candidates := modelCandidates(ci.Conversation)
+
+	for i := ci.modelIndex + 1; i < len(candidates) && ctx.Err() == nil; i++ {
+		candidate := candidates[i]
+
+		provider, err := rt.newProvider(candidate.Provider, candidate.BaseURL)
+		if err != nil {
+			log.Warn().Err(err).Str("model", candidate.String()).Msg("Skipping unavailable fallback model")
+			continue
+		}
+
+		if checkContext {
+			inputTokens, err := provider.EstimateInputTokens(candidate.Model, ci.Messages)
+			if err != nil || provider.CheckContextWindow(candidate.Model, inputTokens, 100) != nil {
+				log.Warn().Str("model", candidate.String()).Msg("Skipping fallback model with a smaller context window")
+				continue
+			}
+		}
+
+		fallback := &ModelFallback{From: ci.model, To: candidate, Reason: reason}
+
+		rt.useModel(ci, i, provider)
+
+		// The stored responses belong to the previous model
+		ci.LastResponseID = ""
+		ci.LastResponseMessages = 0
+
+		log.Warn().
+			Str("Name", ci.AgentName).
+			Str("ID", ci.ID.String()).
+			Str("from", fallback.From.String()).
+			Str("to", fallback.To.String()).
+			Str("reason", reason).
+			Msg("Falling back to the next model")
+
+		ci.emit(ConversationEvent{Type: ConversationEventFallback, Fallback: fallback})
+
+		return true
+	}
+
+	return false
+}
+
+// shouldFallBack reports whether a failed provider call may succeed on another model:
+// the provider is unavailable or the conversation doesn't fit its context window.
+func shouldFallBack(err error) bool {
+	providerErr, ok := types.AsProviderError(err)
+	if ok && providerErr.Class == types.ErrorClassContextLength {
+		return true
+	}
+
+	return ez.ErrorCode(err) == ez.EUNAVAILABLE
+}
+
+// recordStepUsage adds the tokens of a provider call to the conversation totals and
+// to the record of its step, pricing them with the catalog entry of the model that
+// served it.
+func (ci *ConversationInstance) recordStepUsage(step *agent.ConversationStep, usage types.TokenUsage) {
+	inputTokens := usage.InputTokens - usage.CacheReadInputTokens
+	if inputTokens < 0 {
+		inputTokens = 0
+	}
+
+	// Costs are added up unrounded, calls under half a cent would otherwise cost nothing
+	cost := ci.provider.CalculateCost(ci.model.Model, usage)
+
+	ci.InputTokens += inputTokens
+	ci.OutputTokens += usage.OutputTokens
+	ci.CachedTokens += usage.CacheReadInputTokens
+	ci.Cost += cost
+
+	step.InputTokens = inputTokens
+	step.OutputTokens = usage.OutputTokens
+	step.CachedTokens = usage.CacheReadInputTokens
+	step.Cost = cost
+
+	ci.emitUsage()
+}
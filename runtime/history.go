@@ -0,0 +1,35 @@
+package runtime
+
+import (
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+)
+
+// threadRequest points the request at the last response the provider stored for the
+// conversation, so only the messages added after it are sent. Stateless conversations
+// and runs served by a fallback model always send the full history.
+func (ci *ConversationInstance) threadRequest(request *types.ChatRequest) {
+	request.Stateless = ci.HistoryMode == agent.HistoryModeStateless
+	request.PreviousResponseID = ""
+	request.PreviousMessageCount = 0
+
+	if request.Stateless || ci.modelIndex != 0 {
+		return
+	}
+
+	request.PreviousResponseID = ci.LastResponseID
+	request.PreviousMessageCount = ci.LastResponseMessages
+}
+
+// trackResponse remembers the response stored for the messages of the request. It is
+// persisted with the conversation so resumed runs can continue the thread.
+func (ci *ConversationInstance) trackResponse(request *types.ChatRequest, response types.ChatResponse) {
+	if request.Stateless || ci.modelIndex != 0 {
+		ci.LastResponseID = ""
+		ci.LastResponseMessages = 0
+		return
+	}
+
+	ci.LastResponseID = response.ID
+	ci.LastResponseMessages = len(request.Messages)
+}
@@ -2,8 +2,6 @@ package runtime
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -14,57 +12,31 @@ import (
 
 type toolCallKey struct{ name, args string }
 
-func (rt *Runtime) RunConversationInstance(ci *ConversationInstance, prompt string) error {
-	const op = "runtime.RunConversationInstance"
-
-	sessionID := fmt.Sprintf("agent:%s", ci.ID)
-
-	rt.scheduler.RunOnce(rt.rootCtx, sessionID, func(jobCtx context.Context) {
-		err := rt.runConversationInstance(jobCtx, ci, prompt)
-		if err != nil {
-			log.Error().Err(err).Str("conversation_id", ci.ID.String()).Msg("conversation failed")
-		}
-	})
-
-	return nil
-}
-
-func (rt *Runtime) runConversationInstance(ctx context.Context, ci *ConversationInstance, prompt string) error {
+// runConversationInstance runs the inference of a claimed conversation. The worker
+// records the final status from the returned error, see finishJob.
+func (rt *Runtime) runConversationInstance(ctx context.Context, ci *ConversationInstance) error {
 	const op = "runtime.runConversationInstance"
 
-	// Step 1: Append the user prompt to the messages and update the status
-	ci.AddMessage(types.MessageRoleUser, prompt)
+	// Step 1: Update the status, the prompt was appended when the run was enqueued
 	ci.Status = agent.ConversationStatusRunning
+	ci.StatusReason = ""
 
 	err := ci.Update(ctx, rt.db)
 	if err != nil {
 		return ez.Wrap(op, err)
 	}
 
+	ci.emitStatus()
+
 	// Step 2: Run any session started hooks
 	ci.RunConversationStartedHook(ctx)
 
 	// Step 3: Run the inference
-	inferenceErr := rt.runInference(ctx, ci)
-	if inferenceErr != nil {
-		if strings.Contains(inferenceErr.Error(), "context canceled") {
-			ci.Status = agent.ConversationStatusCanceled
-		} else {
-			ci.Status = agent.ConversationStatusFailed
-		}
-	}
-
-	pCtx := context.WithoutCancel(ctx)
-	err = ci.Update(pCtx, rt.db)
+	err = rt.runInference(ctx, ci)
 	if err != nil {
 		return ez.Wrap(op, err)
 	}
 
-	if inferenceErr != nil {
-		return ez.Wrap(op, inferenceErr)
-	}
-
-	// Step 4: If there was no error, return the last message content
 	log.Info().Str("conversation_id", ci.ID.String()).Msg("Finished running inference")
 
 	return nil
@@ -73,14 +45,22 @@ func (rt *Runtime) runConversationInstance(ctx context.Context, ci *Conversation
 func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) error {
 	const op = "runtime.ConversationInstance.runInference"
 
-	const maxSteps = 300
-
 	toolCalls := map[toolCallKey]int{}
-	var prevResponseID string // This is for OpenAI
 
-	for step := 0; step < maxSteps; step++ {
+	for {
+		// Steps are numbered over every run of the conversation, so the records of a
+		// resumed or retried run don't repeat the numbers of the earlier ones
+		ci.step = ci.StepCount
 
-		inputTokens, err := ci.provider.EstimateInputTokens(ci.Model, ci.Messages)
+		// Step 1: Stop before the next call once any budget has been spent
+		reason := ci.ExhaustedBudget()
+		if reason != "" {
+			return rt.haltOverBudget(ctx, ci, reason)
+		}
+
+		ci.StepCount++
+
+		inputTokens, err := ci.provider.EstimateInputTokens(ci.model.Model, ci.Messages)
 		if err != nil {
 			return ez.Wrap(op, err)
 		}
@@ -90,7 +70,11 @@ func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) e
 			compactAtPercent = ci.CompactAtPercent
 		}
 
-		err = ci.provider.CheckContextWindow(ci.Model, inputTokens, compactAtPercent)
+		err = ci.provider.CheckContextWindow(ci.model.Model, inputTokens, compactAtPercent)
+		if err != nil && !ci.AutoCompact && rt.fallBack(ctx, ci, true, "context window exceeded") {
+			err = nil
+		}
+
 		if err != nil {
 			// If we exceed context, run any hooks and compact if autoCompact is set
 			if ci.AutoCompact {
@@ -103,23 +87,25 @@ func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) e
 				ci.AddMessage(types.MessageRoleUser, ci.CompactionPrompt)
 
 				chatRequest := types.ChatRequest{
-					Messages:           ci.Messages,
-					PreviousResponseID: prevResponseID,
-					ThinkingEffort:     string(ci.ReasoningEffort),
+					Messages:       ci.Messages,
+					ThinkingEffort: string(ci.ReasoningEffort),
 				}
+				ci.threadRequest(&chatRequest)
+
+				record := ci.startLLMStep(inputTokens)
 
-				compactingResponse, err := ci.provider.Chat(ctx, ci.Model, &chatRequest)
+				compactingResponse, err := ci.provider.Chat(ctx, ci.model.Model, &chatRequest)
 				if err != nil {
+					ci.finishLLMStep(record, err)
+					rt.saveStep(ctx, record)
 					return ez.Wrap(op, err)
 				}
 
-				newInputTokens := compactingResponse.TokenUsage.InputTokens - compactingResponse.TokenUsage.CacheReadInputTokens
-				if newInputTokens < 0 {
-					newInputTokens = 0
-				}
-				ci.InputTokens += newInputTokens
-				ci.OutputTokens += compactingResponse.TokenUsage.OutputTokens
-				ci.CachedTokens += compactingResponse.TokenUsage.CacheReadInputTokens
+				ci.trackResponse(&chatRequest, compactingResponse)
+
+				ci.recordStepUsage(record, compactingResponse.TokenUsage)
+				ci.finishLLMStep(record, nil)
+				rt.saveStep(ctx, record)
 
 				newConversation, err := ci.Clone(ctx, rt.db, true)
 				if err != nil {
@@ -128,13 +114,13 @@ func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) e
 
 				newConversation.CompactCount = ci.CompactCount + 1
 
-				newInstance, err := rt.NewConversationInstance(ctx, newConversation.ID)
+				ci.emitCompaction(newConversation.ID)
+
+				_, err = rt.EnqueueConversation(ctx, newConversation, compactingResponse.Text, nil, ci.priority)
 				if err != nil {
 					return ez.Wrap(op, err)
 				}
 
-				rt.RunConversationInstance(newInstance, compactingResponse.Text)
-
 				ci.RunPostContextCompactionHook(ctx, newConversation.ID)
 
 				return ez.New(op, ez.EINVALID, "Context window exceeded, compacted in new conversation", nil)
@@ -149,98 +135,63 @@ func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) e
 		chatRequest := types.ChatRequest{
 			Messages:               ci.Messages,
 			Tools:                  ci.Tools,
-			PreviousResponseID:     prevResponseID,
 			ThinkingEffort:         string(ci.ReasoningEffort),
 			WebSearch:              ci.WebSearch,
 			StructuredOutputs:      ci.StructuredOutput,
 			StructuredOutputSchema: ci.StructuredOutputSchema,
 		}
 
-		response, err := ci.provider.Chat(ctx, ci.Model, &chatRequest)
-		if err != nil {
-			return ez.Wrap(op, err)
-		}
+		ci.threadRequest(&chatRequest)
 
-		prevResponseID = response.ID // NOTE: This only applies to OpenAI
+		// Retries and fallbacks are part of the same record
+		record := ci.startLLMStep(inputTokens)
 
-		newInputTokens := response.TokenUsage.InputTokens - response.TokenUsage.CacheReadInputTokens
-		if newInputTokens < 0 {
-			newInputTokens = 0
+		response, err := rt.chat(ctx, ci, &chatRequest)
+		for err != nil && shouldFallBack(err) && rt.fallBack(ctx, ci, false, ez.ErrorMessage(err)) {
+			// Response IDs belong to the model that issued them, send the full history
+			ci.threadRequest(&chatRequest)
+			response, err = rt.chat(ctx, ci, &chatRequest)
+		}
+		if err != nil {
+			ci.finishLLMStep(record, err)
+			rt.saveStep(ctx, record)
+			return ez.Wrap(op, err)
 		}
-		ci.InputTokens += newInputTokens
-		ci.OutputTokens += response.TokenUsage.OutputTokens
-		ci.CachedTokens += response.TokenUsage.CacheReadInputTokens
-
-		// Step 3: If we do have tool calls, execute them
-		for _, toolCall := range response.ToolCalls {
-
-			log.Info().
-				Str("Name", ci.AgentName).
-				Str("ID", ci.ID.String()).
-				Str("tool", toolCall.Name).
-				Str("args", toolCall.Arguments).
-				Int("step", step).
-				Msg("Agent made tool call")
-
-			// 3.1 Create a tool call key
-			callKey := toolCallKey{name: toolCall.Name, args: toolCall.Arguments}
-
-			// 3.2 Check that we are not in an infinite loop of tool calls with identical arguments
-			lastStepCall, found := toolCalls[callKey]
-
-			if found && step-lastStepCall <= 1 {
-				toolCalls[callKey] = step
-
-				log.Warn().Str("tool", toolCall.Name).Str("args", toolCall.Arguments).Msg("Skipping tool call due to anti-loop policy")
 
-				// IMPORTANT: Always satisfy the protocol with a ToolMessage for this call_id.
-				// We send a synthetic error payload instead of executing the tool again.
-				syntheticError := `{"error":"duplicate_tool_call","policy":"anti-loop","message":"Duplicate tool call with identical arguments within one step; tool execution skipped."}`
-				ci.AddToolMessage(toolCall.Name, toolCall.CallID, syntheticError)
+		ci.trackResponse(&chatRequest, response)
 
-				continue
-			}
+		ci.recordStepUsage(record, response.TokenUsage)
+		ci.finishLLMStep(record, nil)
+		rt.saveStep(ctx, record)
 
-			// Persist the assistant-issued tool call so resumes have the full transcript.
-			ci.AddAssistantToolCall(toolCall)
+		// Persist reasoning blocks ahead of the tool calls or answer they belong to,
+		// some providers (Anthropic) require them to be sent back verbatim.
+		for _, thinking := range response.Thinking {
+			ci.AddAssistantThinking(thinking)
+		}
 
-			// 3.3 Run any pre-tool-use hooks
-			err = ci.RunPreToolUseHook(ctx, &toolCall, "")
-			if err != nil {
-				// Record the step to help the anti-loop policy.
-				toolCalls[callKey] = step
+		// Summaries are only kept for review, providers never get them back
+		for _, summary := range response.ReasoningSummaries {
+			ci.AddAssistantReasoningSummary(summary)
+		}
 
-				continue
-			}
+		// Step 3: If we do have tool calls, execute them
+		err = rt.runToolCalls(ctx, ci, response.ToolCalls, toolCalls, ci.step)
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
 
-			// 3.4 Call the tool
-			toolCallResponse, err := ci.mcpMux.CallTool(ctx, &toolCall)
+		// 3.1 Check that the agent isn't going around in circles
+		loop := ci.detectLoop(response.ToolCalls)
+		if loop != "" {
+			stopped, err := rt.handleLoop(ctx, ci, loop)
 			if err != nil {
-				return ez.Wrap("agent.ExecuteTool", err)
+				return ez.Wrap(op, err)
 			}
 
-			log.Info().
-				Str("Name", ci.AgentName).
-				Str("ID", ci.ID.String()).
-				Str("tool", toolCall.Name).
-				Str("args", toolCall.Arguments).
-				Str("tool_response", toolCallResponse).
-				Int("step", step).
-				Msg("Tool Call Response")
-
-			// 3.5 Run any post-tool-use hooks
-			err = ci.RunPostToolUseHook(ctx, &toolCall, toolCallResponse)
-			if err != nil {
-				// Record the step to help the anti-loop policy.
-				toolCalls[callKey] = step
-
-				continue
+			if stopped {
+				return nil
 			}
-
-			// 3.6 Record the tool call step
-			toolCalls[callKey] = step
-
-			ci.AddToolMessage(toolCall.Name, toolCall.CallID, toolCallResponse)
 		}
 
 		// Step 4: If we don't have any tool calls
@@ -250,19 +201,34 @@ func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) e
 				Str("Name", ci.AgentName).
 				Str("ID", ci.ID.String()).
 				Str("Response", response.Text).
-				Int("step", step).
+				Int("step", ci.step).
 				Msg("Agent response")
 
-			ci.AddMessage(types.MessageRoleAssistant, response.Text)
+			ci.AddAssistantAnswer(response.Text, response.Citations)
 
-			// 4.1 Update the conversation status to succeeded so that hook don't see it as running
+			// 4.1 Validate the structured output, the model gets a few turns to repair it
+			repairing, err := ci.checkStructuredOutput(response.Text)
+			if err != nil {
+				return ez.Wrap(op, err)
+			}
+
+			if repairing {
+				err = ci.Update(ctx, rt.db)
+				if err != nil {
+					return ez.Wrap(op, err)
+				}
+
+				continue
+			}
+
+			// 4.2 Update the conversation status to succeeded so that hook don't see it as running
 			ci.Status = agent.ConversationStatusSucceeded
 			err = ci.Update(ctx, rt.db)
 			if err != nil {
 				return ez.Wrap(op, err)
 			}
 
-			// 4.2 Check if any hooks want to block the stop
+			// 4.3 Check if any hooks want to block the stop
 			blockStop := false
 
 			err = ci.RunConversationEndedHook(ctx)
@@ -280,12 +246,10 @@ func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) e
 
 			if !blockStop {
 
-				ci.Cost = ci.provider.CalculateCost(ci.Model, ci.InputTokens, ci.OutputTokens, ci.CachedTokens)
-
 				log.Info().
 					Str("Name", ci.AgentName).
 					Str("ID", ci.ID.String()).
-					Int("step", step).
+					Int("step", ci.step).
 					Msg("Agent finished")
 				return nil
 			}
@@ -296,6 +260,4 @@ func (rt *Runtime) runInference(ctx context.Context, ci *ConversationInstance) e
 			return ez.Wrap(op, err)
 		}
 	}
-
-	return ez.Root(op, ez.ERESOURCEEXHAUSTED, "exceeded maximum inference steps")
 }
@@ -0,0 +1,212 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// defaultLoopWindow bounds the tool calls the loop detector looks at when the
+// conversation doesn't set its own window.
+const defaultLoopWindow = 20
+
+// loopWarning is added for the model when the loop action is warn
+const loopWarning = `You appear to be stuck in a loop: %s.
+
+Repeating these calls will not give a different result. Step back, reconsider your approach and try something else.`
+
+// LoopDetector watches the tool calls of a run, in the order they were made, and
+// reports when the agent keeps repeating itself.
+type LoopDetector interface {
+	// Observe records a tool call, returning a description of the loop it
+	// completes or an empty string.
+	Observe(call types.ToolCall) string
+}
+
+// LoopDetectorFactory creates the detector of a run from the conversation's
+// policy: the most recent calls to look at and the repeats that make a loop.
+type LoopDetectorFactory func(window, threshold int) LoopDetector
+
+// SetLoopDetector replaces the default loop detector. It can be used by programs
+// embedding the runtime and must be called before any conversation runs.
+func (rt *Runtime) SetLoopDetector(factory LoopDetectorFactory) error {
+	const op = "runtime.SetLoopDetector"
+
+	if factory == nil {
+		return ez.New(op, ez.EINVALID, "loop detector factory is nil", nil)
+	}
+
+	rt.loopDetector = factory
+
+	return nil
+}
+
+// newLoopDetector creates the loop detector of a run, nil when detection is off.
+func (rt *Runtime) newLoopDetector(conversation *agent.Conversation) LoopDetector {
+	if conversation.LoopThreshold <= 0 {
+		return nil
+	}
+
+	window := conversation.LoopWindow
+	if window <= 0 {
+		window = defaultLoopWindow
+	}
+
+	return rt.loopDetector(window, conversation.LoopThreshold)
+}
+
+// cycleDetector is the default loop detector. It finds a cycle of one or more
+// calls, e.g. A→A→A or A→B→A→B, repeated threshold times in a row at the end of
+// the window. Arguments are normalized so trivial differences don't hide a loop.
+type cycleDetector struct {
+	window    int
+	threshold int
+	calls     []string // Normalized calls, oldest first
+	names     []string // Tool names of the calls
+}
+
+// NewCycleDetector returns the default loop detector.
+func NewCycleDetector(window, threshold int) LoopDetector {
+	return &cycleDetector{window: window, threshold: threshold}
+}
+
+func (d *cycleDetector) Observe(call types.ToolCall) string {
+	d.calls = append(d.calls, call.Name+" "+normalizeArguments(call.Arguments))
+	d.names = append(d.names, call.Name)
+
+	if len(d.calls) > d.window {
+		d.calls = d.calls[1:]
+		d.names = d.names[1:]
+	}
+
+	for length := 1; length*d.threshold <= len(d.calls); length++ {
+		if !d.repeats(length) {
+			continue
+		}
+
+		cycle := d.names[len(d.names)-length:]
+
+		// Start over so the same loop is only reported once it repeats again
+		d.calls = nil
+		d.names = nil
+
+		if length == 1 {
+			return fmt.Sprintf("%s was called %d times in a row with the same arguments", cycle[0], d.threshold)
+		}
+
+		return fmt.Sprintf("the calls %s were repeated %d times in a row", strings.Join(cycle, " → "), d.threshold)
+	}
+
+	return ""
+}
+
+// repeats reports whether the last calls are the same cycle of the given length
+// repeated threshold times.
+func (d *cycleDetector) repeats(length int) bool {
+	last := d.calls[len(d.calls)-length:]
+
+	for i := 2; i <= d.threshold; i++ {
+		end := len(d.calls) - (i-1)*length
+		if !slices.Equal(d.calls[end-length:end], last) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeArguments returns the arguments of a call in a canonical form: keys
+// sorted and whitespace collapsed in strings. Letter case is kept, paths and flags
+// like -i and -I differ by it. Arguments that are not JSON are only trimmed.
+func normalizeArguments(arguments string) string {
+	value, err := types.DecodeJSON(arguments)
+	if err != nil {
+		return strings.TrimSpace(arguments)
+	}
+
+	normalized, err := json.Marshal(normalizeValue(value))
+	if err != nil {
+		return strings.TrimSpace(arguments)
+	}
+
+	return string(normalized)
+}
+
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeValue(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// detectLoop passes the tool calls of a step to the loop detector, returning the
+// first loop they complete.
+func (ci *ConversationInstance) detectLoop(calls []types.ToolCall) string {
+	if ci.loops == nil {
+		return ""
+	}
+
+	found := ""
+	for _, call := range calls {
+		loop := ci.loops.Observe(call)
+		if found == "" {
+			found = loop
+		}
+	}
+
+	return found
+}
+
+// handleLoop applies the conversation's loop action, returning true when the run
+// was stopped.
+func (rt *Runtime) handleLoop(ctx context.Context, ci *ConversationInstance, loop string) (bool, error) {
+	const op = "runtime.handleLoop"
+
+	log.Warn().
+		Str("Name", ci.AgentName).
+		Str("ID", ci.ID.String()).
+		Str("loop", loop).
+		Str("action", string(ci.LoopAction)).
+		Int("step", ci.step).
+		Msg("Agent loop detected")
+
+	switch ci.LoopAction {
+	case agent.LoopActionStop:
+		ci.Status = agent.ConversationStatusLooping
+		ci.StatusReason = loop
+
+		err := ci.Update(ctx, rt.db)
+		if err != nil {
+			return false, ez.Wrap(op, err)
+		}
+
+		return true, nil
+
+	case agent.LoopActionHook:
+		ci.RunLoopDetectedHook(ctx, loop)
+
+	default:
+		ci.AddMessage(types.MessageRoleUser, fmt.Sprintf(loopWarning, loop))
+	}
+
+	return false, nil
+}
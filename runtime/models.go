@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// modelListTTL is how long the models listed by a provider are reused.
+const modelListTTL = 10 * time.Minute
+
+type modelList struct {
+	models    []string
+	fetchedAt time.Time
+}
+
+// modelLists caches the models listed by each provider/base URL. Failed listings
+// are not cached.
+type modelLists struct {
+	mu    sync.Mutex
+	lists map[string]modelList
+}
+
+// Providers returns the registered providers sorted by name.
+func (rt *Runtime) Providers() []agent.LLMProvider {
+	rt.providersMu.RLock()
+	defer rt.providersMu.RUnlock()
+
+	providers := make([]agent.LLMProvider, 0, len(rt.providers))
+	for provider := range rt.providers {
+		providers = append(providers, provider)
+	}
+
+	sort.Slice(providers, func(i, j int) bool { return providers[i] < providers[j] })
+
+	return providers
+}
+
+// ListModels returns the models the credentials of the provider can use, sorted
+// by ID. Listings are cached for a few minutes.
+func (rt *Runtime) ListModels(ctx context.Context, provider agent.LLMProvider, baseURL string) ([]string, error) {
+	const op = "runtime.ListModels"
+
+	key := fmt.Sprintf("%s|%s", provider, baseURL)
+
+	rt.modelLists.mu.Lock()
+	cached, ok := rt.modelLists.lists[key]
+	rt.modelLists.mu.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < modelListTTL {
+		return cached.models, nil
+	}
+
+	llm, err := rt.newProvider(provider, baseURL)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	lister, ok := llm.(types.ModelLister)
+	if !ok {
+		errMsg := fmt.Sprintf("provider %s can't list its models", provider)
+		return nil, ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	slices.Sort(models)
+
+	rt.modelLists.mu.Lock()
+	rt.modelLists.lists[key] = modelList{models: models, fetchedAt: time.Now()}
+	rt.modelLists.mu.Unlock()
+
+	return models, nil
+}
+
+// ValidateModel checks that the provider serves the model. Models in the cached
+// listing of the provider are accepted right away, anything else, e.g. an alias,
+// is checked with the provider.
+func (rt *Runtime) ValidateModel(ctx context.Context, provider agent.LLMProvider, baseURL, model string) error {
+	const op = "runtime.ValidateModel"
+
+	llm, err := rt.newProvider(provider, baseURL)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if _, ok := llm.(types.ModelLister); ok && model != "" {
+		models, err := rt.ListModels(ctx, provider, baseURL)
+		if err == nil && slices.Contains(models, model) {
+			return nil
+		}
+	}
+
+	err = llm.ValidateModel(ctx, model)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	anthropicoption "github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+
+	"github.com/vanclief/agent-composer/core/controller"
+	"github.com/vanclief/agent-composer/models/agent"
+	anthropicprovider "github.com/vanclief/agent-composer/runtime/providers/anthropic"
+	"github.com/vanclief/agent-composer/runtime/providers/chatgpt"
+	"github.com/vanclief/agent-composer/runtime/providers/openaicompat"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// ProviderOptions carries the per-conversation settings a provider factory may need.
+type ProviderOptions struct {
+	BaseURL string
+}
+
+// ProviderFactory builds the provider used by a single conversation. Factories own
+// their API clients and should create them on first use, so an unused provider
+// never requires credentials.
+type ProviderFactory func(opts ProviderOptions) (types.LLMProvider, error)
+
+// RegisterProvider makes a provider available to specs and conversations. It can be
+// used by programs embedding the runtime to add their own providers or replace a
+// built-in one. Values outside the built-in enum are added to the accepted set.
+// Providers should price their calls with rt.Catalog().Provider(provider).
+func (rt *Runtime) RegisterProvider(provider agent.LLMProvider, factory ProviderFactory) error {
+	const op = "runtime.RegisterProvider"
+
+	if strings.TrimSpace(string(provider)) == "" {
+		return ez.New(op, ez.EINVALID, "provider is required", nil)
+	}
+
+	if factory == nil {
+		return ez.New(op, ez.EINVALID, "provider factory is nil", nil)
+	}
+
+	rt.providersMu.Lock()
+	defer rt.providersMu.Unlock()
+
+	if rt.providers == nil {
+		rt.providers = make(map[agent.LLMProvider]ProviderFactory)
+	}
+
+	if provider.Validate() != nil {
+		agent.RegisterLLMProvider(provider)
+	}
+
+	rt.providers[provider] = factory
+
+	return nil
+}
+
+// newProvider returns the LLM provider implementation registered for the given enum value.
+func (rt *Runtime) newProvider(provider agent.LLMProvider, baseURL string) (types.LLMProvider, error) {
+	const op = "runtime.newProvider"
+
+	rt.providersMu.RLock()
+	factory, ok := rt.providers[provider]
+	rt.providersMu.RUnlock()
+
+	if !ok {
+		errMsg := fmt.Sprintf("unsupported LLM provider %s", provider)
+		return nil, ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	llm, err := factory(ProviderOptions{BaseURL: baseURL})
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	return llm, nil
+}
+
+// registerBuiltinProviders registers the providers shipped with the runtime.
+func (rt *Runtime) registerBuiltinProviders(cfg controller.ProvidersConfig) error {
+	const op = "runtime.registerBuiltinProviders"
+
+	openaiClient := &lazyClient[openai.Client]{init: func() (*openai.Client, error) {
+		return newOpenAIClient(cfg.OpenAI)
+	}}
+
+	anthropicClient := &lazyClient[anthropic.Client]{init: func() (*anthropic.Client, error) {
+		return newAnthropicClient(cfg.Anthropic)
+	}}
+
+	builtin := map[agent.LLMProvider]ProviderFactory{
+		agent.LLMProviderOpenAI: func(opts ProviderOptions) (types.LLMProvider, error) {
+			client, err := openaiClient.get()
+			if err != nil {
+				return nil, err
+			}
+			return chatgpt.New(client, rt.catalog.Provider(agent.LLMProviderOpenAI))
+		},
+		agent.LLMProviderAnthropic: func(opts ProviderOptions) (types.LLMProvider, error) {
+			client, err := anthropicClient.get()
+			if err != nil {
+				return nil, err
+			}
+			return anthropicprovider.New(client, rt.catalog.Provider(agent.LLMProviderAnthropic))
+		},
+		agent.LLMProviderOpenAICompatible: func(opts ProviderOptions) (types.LLMProvider, error) {
+			// Local servers usually don't need a key, so a missing one is not an error
+			apiKey := settingOrEnv(cfg.OpenAICompatible.APIKey, "OPENAI_COMPATIBLE_API_KEY")
+			return openaicompat.New(opts.BaseURL, apiKey, rt.catalog.Provider(agent.LLMProviderOpenAICompatible))
+		},
+	}
+
+	for provider, factory := range builtin {
+		err := rt.RegisterProvider(provider, factory)
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
+	return nil
+}
+
+func newOpenAIClient(settings controller.ProviderSettings) (*openai.Client, error) {
+	const op = "runtime.newOpenAIClient"
+
+	apiKey := settingOrEnv(settings.APIKey, "OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, ez.New(op, ez.EUNAVAILABLE, "OpenAI provider is not configured, set OPENAI_API_KEY", nil)
+	}
+
+	// Retries are handled by the runtime, see resilientProvider
+	opts := []option.RequestOption{option.WithAPIKey(apiKey), option.WithMaxRetries(0)}
+	if settings.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(settings.BaseURL))
+	}
+
+	client := openai.NewClient(opts...)
+
+	return &client, nil
+}
+
+func newAnthropicClient(settings controller.ProviderSettings) (*anthropic.Client, error) {
+	const op = "runtime.newAnthropicClient"
+
+	apiKey := settingOrEnv(settings.APIKey, "ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return nil, ez.New(op, ez.EUNAVAILABLE, "Anthropic provider is not configured, set ANTHROPIC_API_KEY", nil)
+	}
+
+	// Retries are handled by the runtime, see resilientProvider
+	opts := []anthropicoption.RequestOption{anthropicoption.WithAPIKey(apiKey), anthropicoption.WithMaxRetries(0)}
+	if settings.BaseURL != "" {
+		opts = append(opts, anthropicoption.WithBaseURL(settings.BaseURL))
+	}
+
+	client := anthropic.NewClient(opts...)
+
+	return &client, nil
+}
+
+// settingOrEnv prefers the value from the config file and falls back to the env var.
+func settingOrEnv(value, envVar string) string {
+	value = strings.TrimSpace(value)
+	if value != "" {
+		return value
+	}
+
+	return strings.TrimSpace(os.Getenv(envVar))
+}
+
+// lazyClient creates a client on first use. Failures are not cached so a provider
+// can become available once its credentials are set.
+type lazyClient[T any] struct {
+	mu     sync.Mutex
+	client *T
+	init   func() (*T, error)
+}
+
+func (l *lazyClient[T]) get() (*T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.client != nil {
+		return l.client, nil
+	}
+
+	client, err := l.init()
+	if err != nil {
+		return nil, err
+	}
+
+	l.client = client
+
+	return client, nil
+}
@@ -0,0 +1,18 @@
+package anthropic
+
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/vanclief/agent-composer/runtime/catalog"
+	"github.com/vanclief/agent-composer/runtime/types"
+)
+
+type Claude struct {
+	client *anthropic.Client
+	models *catalog.ProviderModels
+}
+
+func New(client *anthropic.Client, models *catalog.ProviderModels) (types.LLMProvider, error) {
+	claude := &Claude{client: client, models: models}
+
+	return claude, nil
+}
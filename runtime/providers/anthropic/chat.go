@@ -0,0 +1,418 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+func (claude *Claude) Chat(ctx context.Context, model string, request *types.ChatRequest) (types.ChatResponse, error) {
+	const op = "Claude.Chat"
+
+	// Step 1) Create the request
+	params, err := claude.newMessageParams(model, request)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
+
+	// Step 2) Call the Anthropic API
+	log.Info().Msg("Doing LLM things...")
+	response, err := claude.client.Messages.New(ctx, params)
+	if err != nil {
+		providerErr := providerError(err)
+		return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Messages API call failed", providerErr)
+	}
+
+	return toChatResponse(model, response), nil
+}
+
+// newMessageParams builds the Messages API request. The API is stateless, so the
+// full history is always sent.
+func (claude *Claude) newMessageParams(model string, request *types.ChatRequest) (anthropic.MessageNewParams, error) {
+	const op = "Claude.newMessageParams"
+
+	system, messages := messagesToAnthropicParams(request.Messages)
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: claude.maxOutputTokens(model),
+		System:    system,
+		Messages:  messages,
+	}
+
+	// If there are any tools create them
+	tools := make([]anthropic.ToolUnionParam, 0, len(request.Tools)+1)
+
+	if len(request.Tools) > 0 {
+		functionTools, err := buildFunctionTools(request.Tools)
+		if err != nil {
+			return params, ez.New(op, ez.EINVALID, "invalid tool definition", err)
+		}
+
+		tools = append(tools, functionTools...)
+	}
+
+	if request.WebSearch {
+		tools = append(tools, anthropic.ToolUnionParam{
+			OfWebSearchTool20250305: &anthropic.WebSearchTool20250305Param{},
+		})
+	}
+
+	if len(tools) > 0 {
+		params.Tools = tools
+	}
+
+	if request.StructuredOutputs {
+		if len(request.StructuredOutputSchema) == 0 {
+			return params, ez.New(op, ez.EINVALID, "structured outputs enabled but schema is empty", nil)
+		}
+
+		params.OutputConfig = anthropic.OutputConfigParam{
+			Format: anthropic.JSONOutputFormatParam{
+				Schema: request.StructuredOutputSchema,
+			},
+		}
+	}
+
+	if claude.isThinkingModel(model) {
+		budget := thinkingBudget(request.ThinkingEffort, params.MaxTokens)
+		params.Thinking = anthropic.ThinkingConfigParamOfEnabled(budget)
+	}
+
+	return params, nil
+}
+
+// toChatResponse converts a finished (or fully accumulated) message into our ChatResponse.
+func toChatResponse(model string, response *anthropic.Message) types.ChatResponse {
+	// Log token usage
+	usage := response.Usage
+	log.Info().
+		Int64("input_tokens", usage.InputTokens).
+		Int64("cache_read_tokens", usage.CacheReadInputTokens).
+		Int64("cache_write_tokens", usage.CacheCreationInputTokens).
+		Int64("output_tokens", usage.OutputTokens).
+		Str("stop_reason", string(response.StopReason)).
+		Msg("Anthropic response")
+
+	// Collect the text, thinking and tool_use blocks
+	var text strings.Builder
+	var thinking []types.Thinking
+	var toolCalls []types.ToolCall
+	var citations []types.Citation
+
+	for _, block := range response.Content {
+		switch block.Type {
+		case "text":
+			// Cited blocks are the span the citation supports
+			start := utf8.RuneCountInString(text.String())
+			for _, citation := range block.Citations {
+				if citation.Type != "web_search_result_location" {
+					continue
+				}
+
+				citations = append(citations, types.Citation{
+					URL:        citation.URL,
+					Title:      citation.Title,
+					CitedText:  citation.CitedText,
+					StartIndex: start,
+					EndIndex:   start + utf8.RuneCountInString(block.Text),
+				})
+			}
+
+			text.WriteString(block.Text)
+		case "thinking":
+			thinking = append(thinking, types.Thinking{
+				Text:      block.Thinking,
+				Signature: block.Signature,
+			})
+		case "redacted_thinking":
+			thinking = append(thinking, types.Thinking{
+				RedactedData: block.Data,
+			})
+		case "tool_use":
+			arguments := string(block.Input)
+			if strings.TrimSpace(arguments) == "" {
+				arguments = "{}"
+			}
+
+			toolCalls = append(toolCalls, types.ToolCall{
+				Name:          block.Name,
+				CallID:        block.ID,
+				Arguments:     arguments,
+				JSONArguments: json.RawMessage(arguments),
+			})
+		default:
+			// Server tool blocks (web search) are resolved by Anthropic and only
+			// surface through the cited text.
+			continue
+		}
+	}
+
+	chatResponse := types.ChatResponse{
+		ID:        response.ID,
+		Model:     model,
+		ToolCalls: toolCalls,
+		Thinking:  thinking,
+		TokenUsage: types.TokenUsage{
+			// Anthropic reports cache reads and writes apart from input_tokens; fold them
+			// back in so InputTokens means the whole prompt like it does for OpenAI.
+			InputTokens:           usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens,
+			OutputTokens:          usage.OutputTokens,
+			CacheReadInputTokens:  usage.CacheReadInputTokens,
+			CacheWriteInputTokens: usage.CacheCreationInputTokens,
+		},
+	}
+
+	if usage.ServerToolUse.WebSearchRequests > 0 {
+		chatResponse.TokenUsage.ToolCalls = map[string]int64{types.ToolCallWebSearch: usage.ServerToolUse.WebSearchRequests}
+	}
+
+	// Only return text when the model is done; text that accompanies tool calls
+	// is intermediate narration.
+	if len(toolCalls) == 0 {
+		chatResponse.Text = text.String()
+		chatResponse.Citations = citations
+	}
+
+	return chatResponse
+}
+
+// messagesToAnthropicParams converts our generic Message slice into the Messages API shape.
+// System messages go into the top-level system prompt, tool results are sent as user turns,
+// and consecutive messages with the same role are merged into a single turn.
+func messagesToAnthropicParams(messages []types.Message) ([]anthropic.TextBlockParam, []anthropic.MessageParam) {
+	var system []anthropic.TextBlockParam
+	params := make([]anthropic.MessageParam, 0, len(messages))
+
+	appendBlock := func(role anthropic.MessageParamRole, block anthropic.ContentBlockParamUnion) {
+		last := len(params) - 1
+		if last >= 0 && params[last].Role == role {
+			params[last].Content = append(params[last].Content, block)
+			return
+		}
+
+		params = append(params, anthropic.MessageParam{
+			Role:    role,
+			Content: []anthropic.ContentBlockParamUnion{block},
+		})
+	}
+
+	for _, m := range messages {
+		switch m.Role {
+
+		case types.MessageRoleSystem:
+			if strings.TrimSpace(m.Content) != "" {
+				system = append(system, anthropic.TextBlockParam{Text: m.Content})
+			}
+
+		case types.MessageRoleUser:
+			if len(m.Parts) > 0 {
+				for _, block := range partsToAnthropicBlocks(m.Parts) {
+					appendBlock(anthropic.MessageParamRoleUser, block)
+				}
+			} else if strings.TrimSpace(m.Content) != "" {
+				appendBlock(anthropic.MessageParamRoleUser, anthropic.NewTextBlock(m.Content))
+			}
+
+		case types.MessageRoleAssistant:
+			switch {
+			case m.Thinking != nil:
+				// Only blocks produced by Anthropic carry a signature the API will accept.
+				if m.Thinking.RedactedData != "" {
+					appendBlock(anthropic.MessageParamRoleAssistant, anthropic.NewRedactedThinkingBlock(m.Thinking.RedactedData))
+				} else if m.Thinking.Signature != "" {
+					appendBlock(anthropic.MessageParamRoleAssistant, anthropic.NewThinkingBlock(m.Thinking.Signature, m.Thinking.Text))
+				}
+
+			case m.ReasoningSummary != nil:
+				// Summaries are for reviewers, the model never sees them.
+
+			case m.ToolCall != nil:
+				appendBlock(anthropic.MessageParamRoleAssistant, anthropic.NewToolUseBlock(m.ToolCall.CallID, toolInput(m.ToolCall), m.ToolCall.Name))
+
+			default:
+				if strings.TrimSpace(m.Content) != "" {
+					appendBlock(anthropic.MessageParamRoleAssistant, anthropic.NewTextBlock(m.Content))
+				}
+			}
+
+		case types.MessageRoleTool:
+			// CRITICAL: tool_result must reference the tool_use id from the previous assistant turn
+			appendBlock(anthropic.MessageParamRoleUser, anthropic.NewToolResultBlock(m.ToolCallID, m.Content, false))
+
+		default:
+			// ignore or handle other roles
+		}
+	}
+
+	return system, params
+}
+
+// partsToAnthropicBlocks converts multimodal content into Messages API blocks.
+// Files are sent as documents, PDFs by URL or base64 and text files inline.
+func partsToAnthropicBlocks(parts []types.ContentPart) []anthropic.ContentBlockParamUnion {
+	blocks := make([]anthropic.ContentBlockParamUnion, 0, len(parts))
+
+	for _, part := range parts {
+		var block anthropic.ContentBlockParamUnion
+
+		switch {
+		case part.Type == types.ContentPartText:
+			block = anthropic.NewTextBlock(part.Text)
+
+		case part.Type == types.ContentPartImage && part.URL != "":
+			block = anthropic.NewImageBlock(anthropic.URLImageSourceParam{URL: part.URL})
+
+		case part.Type == types.ContentPartImage:
+			block = anthropic.NewImageBlockBase64(part.MediaType, part.Data)
+
+		case part.URL != "":
+			block = anthropic.NewDocumentBlock(anthropic.URLPDFSourceParam{URL: part.URL})
+
+		case part.MediaType == "text/plain":
+			text, err := base64.StdEncoding.DecodeString(part.Data)
+			if err != nil {
+				log.Warn().Err(err).Str("filename", part.Filename).Msg("Skipping text file that is not base64 encoded")
+				continue
+			}
+
+			block = anthropic.NewDocumentBlock(anthropic.PlainTextSourceParam{Data: string(text)})
+
+		default:
+			block = anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{Data: part.Data})
+		}
+
+		if block.OfDocument != nil && part.Filename != "" {
+			block.OfDocument.Title = anthropic.String(part.Filename)
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+// toolInput returns the tool call arguments as a JSON object, falling back to an
+// empty object when the model produced something that doesn't parse.
+func toolInput(call *types.ToolCall) json.RawMessage {
+	raw := strings.TrimSpace(call.Arguments)
+	if raw == "" && len(call.JSONArguments) > 0 {
+		raw = string(call.JSONArguments)
+	}
+
+	var object map[string]any
+	err := json.Unmarshal([]byte(raw), &object)
+	if err != nil || object == nil {
+		return json.RawMessage("{}")
+	}
+
+	return json.RawMessage(raw)
+}
+
+func buildFunctionTools(toolDefs []types.ToolDefinition) ([]anthropic.ToolUnionParam, error) {
+	const op = "Claude.buildFunctionTools"
+
+	var toolParams []anthropic.ToolUnionParam
+
+	for _, definition := range toolDefs {
+		if definition.Name == "" {
+			return nil, ez.New(op, ez.EINVALID, "tool name is required", nil)
+		}
+
+		schema := anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{},
+		}
+
+		extra := map[string]any{}
+		for key, value := range definition.JSONSchema {
+			switch key {
+			case "type":
+				// Always "object"; set by the SDK.
+			case "properties":
+				schema.Properties = value
+			case "required":
+				schema.Required = toStringSlice(value)
+			default:
+				extra[key] = value
+			}
+		}
+
+		if len(extra) > 0 {
+			schema.ExtraFields = extra
+		}
+
+		tool := anthropic.ToolUnionParamOfTool(schema, definition.Name)
+		if definition.Description != "" {
+			tool.OfTool.Description = anthropic.String(definition.Description)
+		}
+
+		toolParams = append(toolParams, tool)
+	}
+
+	return toolParams, nil
+}
+
+func toStringSlice(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// isThinkingModel reports whether the model supports extended thinking, guessing
+// from its name when it is not in the catalog.
+func (claude *Claude) isThinkingModel(model string) bool {
+	entry, ok := claude.models.Lookup(model)
+	if ok {
+		return entry.Capabilities.Reasoning
+	}
+
+	m := strings.ToLower(model)
+	return strings.HasPrefix(m, "claude-opus-4") ||
+		strings.HasPrefix(m, "claude-sonnet-4") ||
+		strings.HasPrefix(m, "claude-haiku-4") ||
+		strings.HasPrefix(m, "claude-3-7-sonnet")
+}
+
+// thinkingBudget maps our reasoning effort to an extended thinking budget. The
+// budget must stay below max_tokens, and Anthropic requires at least 1024.
+func thinkingBudget(effort string, maxTokens int64) int64 {
+	var budget int64
+
+	switch types.ReasoningEffort(effort) {
+	case types.ReasoningEffortHigh:
+		budget = 12288
+	case types.ReasoningEffortMedium:
+		budget = 4096
+	default:
+		budget = 1024
+	}
+
+	if budget >= maxTokens {
+		budget = maxTokens / 2
+	}
+
+	if budget < 1024 {
+		budget = 1024
+	}
+
+	return budget
+}
@@ -0,0 +1,27 @@
+package anthropic
+
+import "github.com/vanclief/ez"
+
+func (claude *Claude) CheckContextWindow(model string, totalInputTokens, compactAtPercent int) error {
+	err := claude.models.CheckContextWindow(model, totalInputTokens, compactAtPercent)
+	if err != nil {
+		return ez.Wrap("Claude.CheckContextWindow", err)
+	}
+
+	return nil
+}
+
+// defaultMaxOutput caps the max_tokens sent on each request so requests stay
+// under the SDK's non-streaming time limit.
+const defaultMaxOutput int64 = 16384
+
+// maxOutputTokens is the max_tokens sent on each request, the catalog limit of
+// the model when it is lower than the default.
+func (claude *Claude) maxOutputTokens(model string) int64 {
+	entry, ok := claude.models.Lookup(model)
+	if !ok || entry.MaxOutputTokens <= 0 {
+		return defaultMaxOutput
+	}
+
+	return min(entry.MaxOutputTokens, defaultMaxOutput)
+}
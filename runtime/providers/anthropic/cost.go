@@ -0,0 +1,9 @@
+package anthropic
+
+import "github.com/vanclief/agent-composer/runtime/types"
+
+// CalculateCost returns total USD cents, unrounded, at the catalog prices.
+// Cache writes are billed at the cache write price.
+func (claude *Claude) CalculateCost(model string, usage types.TokenUsage) float64 {
+	return claude.models.Cost(model, usage)
+}
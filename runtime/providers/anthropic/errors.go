@@ -0,0 +1,25 @@
+package anthropic
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/vanclief/agent-composer/runtime/types"
+)
+
+// providerError classifies a failed API call so the runtime can decide whether to
+// retry it.
+func providerError(err error) *types.ProviderError {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		var header http.Header
+		if apiErr.Response != nil {
+			header = apiErr.Response.Header
+		}
+
+		return types.NewProviderError(apiErr.StatusCode, header, err)
+	}
+
+	return types.NewProviderError(0, nil, err)
+}
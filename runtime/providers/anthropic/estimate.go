@@ -0,0 +1,55 @@
+package anthropic
+
+import (
+	"strings"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/vanclief/agent-composer/runtime/types"
+)
+
+// EstimateInputTokens approximates the prompt size with cl100k_base. Claude uses
+// its own tokenizer, which tends to produce more tokens for the same text, so the
+// count is padded to stay on the safe side of the context window check.
+func (claude *Claude) EstimateInputTokens(model string, messages []types.Message) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	tke, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return 0, err
+	}
+
+	count := len(tke.Encode(simulatePayload(messages), nil, nil))
+
+	return count + count/tokenizerPaddingDivisor + types.EstimatePartTokens(messages), nil
+}
+
+// tokenizerPaddingDivisor adds ~15% on top of the cl100k_base estimate.
+const tokenizerPaddingDivisor = 7
+
+func simulatePayload(messages []types.Message) string {
+	var b strings.Builder
+
+	for _, msg := range messages {
+		b.WriteString(string(msg.Role))
+		b.WriteString(": ")
+
+		switch {
+		case msg.ToolCall != nil:
+			b.WriteString(msg.ToolCall.Name)
+			b.WriteString(" ")
+			b.WriteString(msg.ToolCall.Arguments)
+		case msg.Thinking != nil:
+			b.WriteString(msg.Thinking.Text)
+		case msg.ReasoningSummary != nil:
+			// Summaries are not sent back to the Messages API.
+		default:
+			b.WriteString(msg.Content)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package anthropic
+
+import (
+	"context"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/vanclief/ez"
+)
+
+// ListModels returns the IDs of every model the API key can use. Aliases such as
+// "claude-sonnet-4-5" are not listed, only the dated snapshots they point to.
+func (claude *Claude) ListModels(ctx context.Context) ([]string, error) {
+	const op = "Claude.ListModels"
+
+	var models []string
+
+	iter := claude.client.Models.ListAutoPaging(ctx, anthropic.ModelListParams{})
+	for iter.Next() {
+		models = append(models, iter.Current().ID)
+	}
+
+	err := iter.Err()
+	if err != nil {
+		providerErr := providerError(err)
+		return nil, ez.New(op, providerErr.Class.Code(), "failed to list Anthropic models", providerErr)
+	}
+
+	return models, nil
+}
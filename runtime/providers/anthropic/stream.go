@@ -0,0 +1,76 @@
+package anthropic
+
+import (
+	"context"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+func (claude *Claude) ChatStream(ctx context.Context, model string, request *types.ChatRequest, onEvent types.StreamHandler) (types.ChatResponse, error) {
+	const op = "Claude.ChatStream"
+
+	// Step 1) Create the request
+	params, err := claude.newMessageParams(model, request)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
+
+	// Step 2) Open the stream
+	log.Info().Msg("Doing LLM things...")
+	stream := claude.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	message := anthropic.Message{}
+
+	// Step 3) Forward the deltas while accumulating the full message
+	for stream.Next() {
+		event := stream.Current()
+
+		err = message.Accumulate(event)
+		if err != nil {
+			return types.ChatResponse{}, ez.New(op, ez.EINTERNAL, "failed to accumulate stream event", err)
+		}
+
+		// Accumulate only keeps the output tokens of the final usage
+		if event.Type == "message_delta" {
+			message.Usage.ServerToolUse = event.Usage.ServerToolUse
+		}
+
+		if event.Type != "content_block_delta" {
+			continue
+		}
+
+		switch event.Delta.Type {
+		case "text_delta":
+			onEvent(types.StreamEvent{Type: types.StreamEventTextDelta, Delta: event.Delta.Text})
+
+		case "thinking_delta":
+			onEvent(types.StreamEvent{Type: types.StreamEventReasoningDelta, Delta: event.Delta.Thinking})
+
+		case "input_json_delta":
+			// Deltas always belong to the block that was started last, skip server tools
+			block := message.Content[len(message.Content)-1]
+			if block.Type != "tool_use" {
+				continue
+			}
+
+			onEvent(types.StreamEvent{
+				Type:       types.StreamEventToolCallDelta,
+				Delta:      event.Delta.PartialJSON,
+				ToolCallID: block.ID,
+				ToolName:   block.Name,
+			})
+		}
+	}
+
+	err = stream.Err()
+	if err != nil {
+		providerErr := providerError(err)
+		return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Messages API stream failed", providerErr)
+	}
+
+	return toChatResponse(model, &message), nil
+}
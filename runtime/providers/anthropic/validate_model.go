@@ -0,0 +1,27 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/vanclief/ez"
+)
+
+func (claude *Claude) ValidateModel(ctx context.Context, model string) error {
+	const op = "Claude.ValidateModel"
+
+	if model == "" {
+		return ez.New(op, ez.EINVALID, "model is required", nil)
+	}
+
+	// Uses the official SDK's Models service (Get) to verify the model ID.
+	// Any 4xx/5xx from the API bubbles up here.
+	_, err := claude.client.Models.Get(ctx, model, anthropic.ModelGetParams{})
+	if err != nil {
+		errMsg := fmt.Sprintf("Anthropic model %s does not exist", model)
+		return ez.New(op, ez.EINVALID, errMsg, err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/packages/param"
@@ -17,29 +18,47 @@ import (
 func (gpt *ChatGPT) Chat(ctx context.Context, model string, request *types.ChatRequest) (types.ChatResponse, error) {
 	const op = "ChatGPT.Chat"
 
-	originalMessageCount := len(request.Messages)
+	// Step 1) Create the request
+	params, err := gpt.newResponseParams(model, request)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
 
-	// Step 1) Only pass the messages delta if continuing a previous response
-	if request.PreviousResponseID != "" {
-		lastMsg, ok := gpt.responsesToMessages[request.PreviousResponseID]
-		if ok {
-			if lastMsg <= len(request.Messages) {
-				request.Messages = request.Messages[lastMsg:]
-			} else {
-				request.Messages = request.Messages[:0]
-			}
+	// Step 2) Call the ChatGPT API
+	log.Info().Msg("Doing LLM things...")
+	response, err := gpt.client.Responses.New(ctx, params)
+	if err != nil {
+		providerErr := providerError(err)
+		return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Responses API call failed", providerErr)
+	}
 
-			if len(request.Messages) > 0 {
-				filtered := make([]types.Message, 0, len(request.Messages))
-				for _, msg := range request.Messages {
-					// Skip assistant replies that don't contain tool calls; they were already sent.
-					if msg.Role == types.MessageRoleAssistant && msg.ToolCall == nil {
-						continue
-					}
-					filtered = append(filtered, msg)
-				}
-				request.Messages = filtered
+	return gpt.toChatResponse(model, response), nil
+}
+
+// newResponseParams builds the Responses API request. When continuing a previous
+// response only the messages added after it are sent.
+func (gpt *ChatGPT) newResponseParams(model string, request *types.ChatRequest) (responses.ResponseNewParams, error) {
+	const op = "ChatGPT.newResponseParams"
+
+	messages := request.Messages
+	previousResponseID := request.PreviousResponseID
+
+	// A thread that doesn't match the history can't be continued, send everything instead
+	if request.Stateless || request.PreviousMessageCount <= 0 || request.PreviousMessageCount > len(messages) {
+		previousResponseID = ""
+	}
+
+	// Step 1) Only pass the messages delta if continuing a previous response
+	if previousResponseID != "" {
+		delta := messages[request.PreviousMessageCount:]
+
+		messages = make([]types.Message, 0, len(delta))
+		for _, msg := range delta {
+			// Skip assistant replies that don't contain tool calls; they were already sent.
+			if msg.Role == types.MessageRoleAssistant && msg.ToolCall == nil {
+				continue
 			}
+			messages = append(messages, msg)
 		}
 	}
 
@@ -47,13 +66,17 @@ func (gpt *ChatGPT) Chat(ctx context.Context, model string, request *types.ChatR
 	params := responses.ResponseNewParams{
 		Model: shared.ResponsesModel(model),
 		Input: responses.ResponseNewParamsInputUnion{
-			OfInputItemList: messagesToResponsesInputParam(request.Messages),
+			OfInputItemList: messagesToResponsesInputParam(messages),
 		},
 	}
 
 	// If it has a prev response ID, set it to continue the thread
-	if request.PreviousResponseID != "" {
-		params.PreviousResponseID = openai.String(request.PreviousResponseID)
+	if previousResponseID != "" {
+		params.PreviousResponseID = openai.String(previousResponseID)
+	}
+
+	if request.Stateless {
+		params.Store = openai.Bool(false)
 	}
 
 	// If there are are any tool calls create them
@@ -62,7 +85,7 @@ func (gpt *ChatGPT) Chat(ctx context.Context, model string, request *types.ChatR
 	if len(request.Tools) > 0 {
 		functionTools, err := buildFunctionTools(request.Tools)
 		if err != nil {
-			return types.ChatResponse{}, ez.New(op, ez.EINVALID, "invalid tool definition", err)
+			return params, ez.New(op, ez.EINVALID, "invalid tool definition", err)
 		}
 
 		tools = append(tools, functionTools...)
@@ -79,7 +102,7 @@ func (gpt *ChatGPT) Chat(ctx context.Context, model string, request *types.ChatR
 
 	if request.StructuredOutputs {
 		if len(request.StructuredOutputSchema) == 0 {
-			return types.ChatResponse{}, ez.New(op, ez.EINVALID, "structured outputs enabled but schema is empty", nil)
+			return params, ez.New(op, ez.EINVALID, "structured outputs enabled but schema is empty", nil)
 		}
 
 		format := responses.ResponseFormatTextConfigParamOfJSONSchema("structured_output", request.StructuredOutputSchema)
@@ -92,19 +115,18 @@ func (gpt *ChatGPT) Chat(ctx context.Context, model string, request *types.ChatR
 		}
 	}
 
-	if isReasoningModel(model) {
+	if gpt.isReasoningModel(model) {
 		params.Reasoning = shared.ReasoningParam{
-			Effort: shared.ReasoningEffort(request.ThinkingEffort), // "low" | "medium" | "high"
+			Effort:  shared.ReasoningEffort(request.ThinkingEffort), // "low" | "medium" | "high"
+			Summary: shared.ReasoningSummaryAuto,                    // Readable summary of the hidden reasoning
 		}
 	}
 
-	// Step 3) Call the ChatGPT API
-	log.Info().Msg("Doing LLM things...")
-	response, err := gpt.client.Responses.New(ctx, params)
-	if err != nil {
-		return types.ChatResponse{}, ez.New(op, ez.EINTERNAL, "Responses API call failed", err)
-	}
+	return params, nil
+}
 
+// toChatResponse converts a finished Responses API response into our ChatResponse.
+func (gpt *ChatGPT) toChatResponse(model string, response *responses.Response) types.ChatResponse {
 	// Log token usage
 	usage, _ := extractTokenUsage(response)
 	log.Info().
@@ -115,30 +137,24 @@ func (gpt *ChatGPT) Chat(ctx context.Context, model string, request *types.ChatR
 		Int64("total_tokens", usage.TotalTokens).
 		Msg("OpenAI response")
 
-	// Exit 1) If the response is not empty, return it
-	if response.OutputText() != "" {
-		gpt.responsesToMessages[response.ID] = originalMessageCount
-		return types.ChatResponse{
-			ID:    response.ID,
-			Text:  response.OutputText(),
-			Model: model,
-			TokenUsage: types.TokenUsage{
-				InputTokens:          usage.InputTokens,
-				OutputTokens:         usage.OutputTokens + usage.OutputTokensDetails.ReasoningTokens,
-				CacheReadInputTokens: usage.InputTokensDetails.CachedTokens,
-			},
-		}, nil
+	tokenUsage := types.TokenUsage{
+		InputTokens:          usage.InputTokens,
+		OutputTokens:         usage.OutputTokens + usage.OutputTokensDetails.ReasoningTokens,
+		CacheReadInputTokens: usage.InputTokensDetails.CachedTokens,
 	}
 
-	// Step 4) Add the response ID to the map to track messages
-	gpt.responsesToMessages[response.ID] = originalMessageCount
-
-	// Step 5) If the response is not empty, probably we have tool calls
 	var toolCalls []types.ToolCall
+	var summaries []types.ReasoningSummary
+
 	for _, outputItem := range response.Output {
 		switch outputItem.Type {
-		case "function_call":
+		case "web_search_call":
+			if tokenUsage.ToolCalls == nil {
+				tokenUsage.ToolCalls = map[string]int64{}
+			}
+			tokenUsage.ToolCalls[types.ToolCallWebSearch]++
 
+		case "function_call":
 			call := outputItem.AsFunctionCall()
 
 			toolCall := types.ToolCall{
@@ -149,25 +165,78 @@ func (gpt *ChatGPT) Chat(ctx context.Context, model string, request *types.ChatR
 			}
 
 			toolCalls = append(toolCalls, toolCall)
+
 		case "reasoning":
-			log.Info().Msg("Reasoning...")
+			// The reasoning itself is hidden, only its summary is readable
+			paragraphs := make([]string, 0, len(outputItem.Summary))
+			for _, summary := range outputItem.Summary {
+				if strings.TrimSpace(summary.Text) != "" {
+					paragraphs = append(paragraphs, summary.Text)
+				}
+			}
 
-			continue
-		default:
-			continue
+			if len(paragraphs) > 0 {
+				summaries = append(summaries, types.ReasoningSummary{
+					ID:   outputItem.ID,
+					Text: strings.Join(paragraphs, "\n\n"),
+				})
+			}
 		}
 	}
 
+	// Exit 1) If the response is not empty, return it
+	if text := response.OutputText(); text != "" {
+		return types.ChatResponse{
+			ID:                 response.ID,
+			Text:               text,
+			Model:              model,
+			ReasoningSummaries: summaries,
+			Citations:          outputCitations(response),
+			TokenUsage:         tokenUsage,
+		}
+	}
+
+	// Step 2) If the response is empty, probably we have tool calls
 	return types.ChatResponse{
-		ID:        response.ID,
-		Model:     model,
-		ToolCalls: toolCalls,
-		TokenUsage: types.TokenUsage{
-			InputTokens:          usage.InputTokens,
-			OutputTokens:         usage.OutputTokens + usage.OutputTokensDetails.ReasoningTokens,
-			CacheReadInputTokens: usage.InputTokensDetails.CachedTokens,
-		},
-	}, nil
+		ID:                 response.ID,
+		Model:              model,
+		ToolCalls:          toolCalls,
+		ReasoningSummaries: summaries,
+		TokenUsage:         tokenUsage,
+	}
+}
+
+// outputCitations returns the URL citations of the output text, with their
+// offsets moved from each text part to the text of OutputText.
+func outputCitations(response *responses.Response) []types.Citation {
+	var citations []types.Citation
+	offset := 0
+
+	// Same traversal as Response.OutputText
+	for _, item := range response.Output {
+		for _, content := range item.Content {
+			if content.Type != "output_text" {
+				continue
+			}
+
+			for _, annotation := range content.Annotations {
+				if annotation.Type != "url_citation" {
+					continue
+				}
+
+				citations = append(citations, types.Citation{
+					URL:        annotation.URL,
+					Title:      annotation.Title,
+					StartIndex: offset + int(annotation.StartIndex),
+					EndIndex:   offset + int(annotation.EndIndex),
+				})
+			}
+
+			offset += utf8.RuneCountInString(content.Text)
+		}
+	}
+
+	return citations
 }
 
 // messagesToResponsesInputParam converts our generic Message slice into the Responses API's
@@ -180,14 +249,25 @@ func messagesToResponsesInputParam(messages []types.Message) responses.ResponseI
 
 		case types.MessageRoleSystem, types.MessageRoleUser:
 			// History is sent as input messages (role: system/user/assistant)
-			inText := responses.ResponseInputContentParamOfInputText(m.Content)
+			content := responses.ResponseInputMessageContentListParam{
+				responses.ResponseInputContentParamOfInputText(m.Content),
+			}
+			if len(m.Parts) > 0 {
+				content = partsToResponsesContent(m.Parts)
+			}
+
 			inMsg := responses.ResponseInputItemMessageParam{
 				Role:    string(m.Role),
-				Content: responses.ResponseInputMessageContentListParam{inText},
+				Content: content,
 			}
 			items = append(items, responses.ResponseInputItemUnionParam{OfInputMessage: &inMsg})
 
 		case types.MessageRoleAssistant:
+			if m.IsReasoning() {
+				// Reasoning blocks and summaries can't be replayed to the Responses API.
+				continue
+			}
+
 			if m.ToolCall != nil {
 				// Persisted function call from the assistant.
 				items = append(items, responses.ResponseInputItemParamOfFunctionCall(
@@ -232,6 +312,53 @@ func messagesToResponsesInputParam(messages []types.Message) responses.ResponseI
 	return items
 }
 
+// partsToResponsesContent converts multimodal content into Responses API input
+// content. Inline images and files are sent as data URLs.
+func partsToResponsesContent(parts []types.ContentPart) responses.ResponseInputMessageContentListParam {
+	content := make(responses.ResponseInputMessageContentListParam, 0, len(parts))
+
+	for _, part := range parts {
+		switch part.Type {
+		case types.ContentPartText:
+			content = append(content, responses.ResponseInputContentParamOfInputText(part.Text))
+
+		case types.ContentPartImage:
+			content = append(content, responses.ResponseInputContentUnionParam{
+				OfInputImage: &responses.ResponseInputImageParam{
+					Detail:   responses.ResponseInputImageDetailAuto,
+					ImageURL: openai.String(part.DataURL()),
+				},
+			})
+
+		case types.ContentPartFile:
+			file := &responses.ResponseInputFileParam{}
+			if part.URL != "" {
+				file.FileURL = openai.String(part.URL)
+			} else {
+				file.FileData = openai.String(part.DataURL())
+				file.Filename = openai.String(fileName(part))
+			}
+
+			content = append(content, responses.ResponseInputContentUnionParam{OfInputFile: file})
+		}
+	}
+
+	return content
+}
+
+// fileName returns the name sent along inline files, which OpenAI requires.
+func fileName(part types.ContentPart) string {
+	if part.Filename != "" {
+		return part.Filename
+	}
+
+	if part.MediaType == "application/pdf" {
+		return "attachment.pdf"
+	}
+
+	return "attachment.txt"
+}
+
 func buildFunctionTools(toolDefs []types.ToolDefinition) ([]responses.ToolUnionParam, error) {
 	const op = "ChatGPT.buildFunctionTools"
 
@@ -273,7 +400,14 @@ func buildFunctionTools(toolDefs []types.ToolDefinition) ([]responses.ToolUnionP
 	return toolParams, nil
 }
 
-func isReasoningModel(model string) bool {
+// isReasoningModel reports whether the model takes a reasoning effort, guessing
+// from its name when it is not in the catalog.
+func (gpt *ChatGPT) isReasoningModel(model string) bool {
+	entry, ok := gpt.models.Lookup(model)
+	if ok {
+		return entry.Capabilities.Reasoning
+	}
+
 	modelLower := strings.ToLower(model)
 	return strings.HasPrefix(modelLower, "gpt-5") || strings.HasPrefix(modelLower, "o")
 }
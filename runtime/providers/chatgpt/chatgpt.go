@@ -2,16 +2,17 @@ package chatgpt
 
 import (
 	"github.com/openai/openai-go"
+	"github.com/vanclief/agent-composer/runtime/catalog"
 	"github.com/vanclief/agent-composer/runtime/types"
 )
 
 type ChatGPT struct {
-	client              *openai.Client
-	responsesToMessages map[string]int
+	client *openai.Client
+	models *catalog.ProviderModels
 }
 
-func New(client *openai.Client) (types.LLMProvider, error) {
-	gpt := &ChatGPT{client: client, responsesToMessages: make(map[string]int)}
+func New(client *openai.Client, models *catalog.ProviderModels) (types.LLMProvider, error) {
+	gpt := &ChatGPT{client: client, models: models}
 
 	return gpt, nil
 }
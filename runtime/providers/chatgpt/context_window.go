@@ -1,24 +1,12 @@
 package chatgpt
 
-import (
-	"fmt"
-	"strings"
-
-	"github.com/vanclief/ez"
-)
+import "github.com/vanclief/ez"
 
 func (gpt *ChatGPT) CheckContextWindow(model string, totalInputTokens, compactAtPercent int) error {
-	maxTokens, ok := ctxWindow[strings.ToLower(model)]
-
-	if ok && totalInputTokens > (maxTokens*compactAtPercent/100) {
-		errMsg := fmt.Sprintf("Input tokens %d exceed context window %d for model %s", totalInputTokens, maxTokens, model)
-		return ez.New("ChatGPT.CheckContextWindow", ez.EINVALID, errMsg, nil)
+	err := gpt.models.CheckContextWindow(model, totalInputTokens, compactAtPercent)
+	if err != nil {
+		return ez.Wrap("ChatGPT.CheckContextWindow", err)
 	}
 
 	return nil
 }
-
-var ctxWindow = map[string]int{
-	"gpt-5": 400000,
-	"o3":    200000,
-}
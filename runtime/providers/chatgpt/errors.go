@@ -0,0 +1,25 @@
+package chatgpt
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/openai/openai-go"
+	"github.com/vanclief/agent-composer/runtime/types"
+)
+
+// providerError classifies a failed API call so the runtime can decide whether to
+// retry it.
+func providerError(err error) *types.ProviderError {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		var header http.Header
+		if apiErr.Response != nil {
+			header = apiErr.Response.Header
+		}
+
+		return types.NewProviderError(apiErr.StatusCode, header, err)
+	}
+
+	return types.NewProviderError(0, nil, err)
+}
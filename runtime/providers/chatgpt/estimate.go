@@ -21,7 +21,7 @@ func (gpt *ChatGPT) EstimateInputTokens(model string, messages []types.Message)
 		return 0, err
 	}
 
-	return len(tke.Encode(simulatedPayload, nil, nil)), nil
+	return len(tke.Encode(simulatedPayload, nil, nil)) + types.EstimatePartTokens(messages), nil
 }
 
 func simulatePayload(messages []types.Message) string {
@@ -0,0 +1,27 @@
+package chatgpt
+
+import (
+	"context"
+
+	"github.com/vanclief/ez"
+)
+
+// ListModels returns the IDs of every model the API key can use.
+func (gpt *ChatGPT) ListModels(ctx context.Context) ([]string, error) {
+	const op = "ChatGPT.ListModels"
+
+	var models []string
+
+	iter := gpt.client.Models.ListAutoPaging(ctx)
+	for iter.Next() {
+		models = append(models, iter.Current().ID)
+	}
+
+	err := iter.Err()
+	if err != nil {
+		providerErr := providerError(err)
+		return nil, ez.New(op, providerErr.Class.Code(), "failed to list OpenAI models", providerErr)
+	}
+
+	return models, nil
+}
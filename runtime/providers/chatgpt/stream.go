@@ -0,0 +1,83 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+
+	"github.com/openai/openai-go/responses"
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+func (gpt *ChatGPT) ChatStream(ctx context.Context, model string, request *types.ChatRequest, onEvent types.StreamHandler) (types.ChatResponse, error) {
+	const op = "ChatGPT.ChatStream"
+
+	// Step 1) Create the request
+	params, err := gpt.newResponseParams(model, request)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
+
+	// Step 2) Open the stream
+	log.Info().Msg("Doing LLM things...")
+	stream := gpt.client.Responses.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	// Function call deltas only reference the output item, keep the call info by item ID
+	type functionCall struct{ callID, name string }
+	functionCalls := map[string]functionCall{}
+
+	var final *responses.Response
+
+	// Step 3) Forward the deltas until the response is done
+	for stream.Next() {
+		event := stream.Current()
+
+		switch event.Type {
+		case "response.output_text.delta":
+			onEvent(types.StreamEvent{Type: types.StreamEventTextDelta, Delta: event.Delta.OfString})
+
+		case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+			onEvent(types.StreamEvent{Type: types.StreamEventReasoningDelta, Delta: event.Delta.OfString})
+
+		case "response.output_item.added":
+			if event.Item.Type == "function_call" {
+				functionCalls[event.Item.ID] = functionCall{callID: event.Item.CallID, name: event.Item.Name}
+			}
+
+		case "response.function_call_arguments.delta":
+			call := functionCalls[event.ItemID]
+			onEvent(types.StreamEvent{
+				Type:       types.StreamEventToolCallDelta,
+				Delta:      event.Delta.OfString,
+				ToolCallID: call.callID,
+				ToolName:   call.name,
+			})
+
+		case "response.completed", "response.incomplete":
+			response := event.Response
+			final = &response
+
+		case "response.failed":
+			providerErr := providerError(errors.New(string(event.Response.Error.Code) + ": " + event.Response.Error.Message))
+			return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Responses API stream failed: "+event.Response.Error.Message, providerErr)
+
+		case "error":
+			providerErr := providerError(errors.New(event.Code + ": " + event.Message))
+			return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Responses API stream error: "+event.Message, providerErr)
+		}
+	}
+
+	err = stream.Err()
+	if err != nil {
+		providerErr := providerError(err)
+		return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Responses API stream failed", providerErr)
+	}
+
+	if final == nil {
+		return types.ChatResponse{}, ez.New(op, ez.EINTERNAL, "Responses API stream ended without a response", nil)
+	}
+
+	return gpt.toChatResponse(model, final), nil
+}
@@ -0,0 +1,281 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+func (compat *OpenAICompat) Chat(ctx context.Context, model string, request *types.ChatRequest) (types.ChatResponse, error) {
+	const op = "OpenAICompat.Chat"
+
+	// Step 1) Create the request
+	params, err := newCompletionParams(model, request)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
+
+	// Step 2) Call the Chat Completions API
+	log.Info().Msg("Doing LLM things...")
+	response, err := compat.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		providerErr := providerError(err)
+		return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Chat Completions API call failed", providerErr)
+	}
+
+	chatResponse, err := compat.toChatResponse(model, response)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
+
+	return chatResponse, nil
+}
+
+// newCompletionParams builds the Chat Completions request. The API is stateless, so
+// the full history is always sent.
+func newCompletionParams(model string, request *types.ChatRequest) (openai.ChatCompletionNewParams, error) {
+	const op = "OpenAICompat.newCompletionParams"
+
+	params := openai.ChatCompletionNewParams{
+		Model:    shared.ChatModel(model),
+		Messages: messagesToChatCompletionParams(request.Messages),
+	}
+
+	// If there are any tools create them
+	if len(request.Tools) > 0 {
+		tools, err := buildFunctionTools(request.Tools)
+		if err != nil {
+			return params, ez.New(op, ez.EINVALID, "invalid tool definition", err)
+		}
+
+		params.Tools = tools
+	}
+
+	if request.WebSearch {
+		log.Warn().Str("model", model).Msg("Web search is not supported by OpenAI-compatible providers, ignoring")
+	}
+
+	if request.StructuredOutputs {
+		if len(request.StructuredOutputSchema) == 0 {
+			return params, ez.New(op, ez.EINVALID, "structured outputs enabled but schema is empty", nil)
+		}
+
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   "structured_output",
+					Schema: request.StructuredOutputSchema,
+					Strict: openai.Bool(true),
+				},
+			},
+		}
+	}
+
+	return params, nil
+}
+
+// toChatResponse converts a finished (or fully accumulated) completion into our ChatResponse.
+func (compat *OpenAICompat) toChatResponse(model string, response *openai.ChatCompletion) (types.ChatResponse, error) {
+	const op = "OpenAICompat.toChatResponse"
+
+	if len(response.Choices) == 0 {
+		return types.ChatResponse{}, ez.New(op, ez.EINTERNAL, "Chat Completions API returned no choices", nil)
+	}
+
+	// Log token usage
+	usage := response.Usage
+	log.Info().
+		Int64("input_tokens", usage.PromptTokens).
+		Int64("cached_tokens", usage.PromptTokensDetails.CachedTokens).
+		Int64("output_tokens", usage.CompletionTokens).
+		Int64("total_tokens", usage.TotalTokens).
+		Str("base_url", compat.baseURL).
+		Msg("OpenAI-compatible response")
+
+	// Collect the text and tool calls
+	message := response.Choices[0].Message
+
+	var toolCalls []types.ToolCall
+	for _, call := range message.ToolCalls {
+		arguments := call.Function.Arguments
+		if strings.TrimSpace(arguments) == "" {
+			arguments = "{}"
+		}
+
+		toolCalls = append(toolCalls, types.ToolCall{
+			Name:          call.Function.Name,
+			CallID:        call.ID,
+			Arguments:     arguments,
+			JSONArguments: json.RawMessage(arguments),
+		})
+	}
+
+	chatResponse := types.ChatResponse{
+		ID:        response.ID,
+		Model:     model,
+		ToolCalls: toolCalls,
+		TokenUsage: types.TokenUsage{
+			InputTokens:          usage.PromptTokens,
+			OutputTokens:         usage.CompletionTokens,
+			CacheReadInputTokens: usage.PromptTokensDetails.CachedTokens,
+		},
+	}
+
+	// Only return text when the model is done; text that accompanies tool calls
+	// is intermediate narration.
+	if len(toolCalls) == 0 {
+		chatResponse.Text = message.Content
+	}
+
+	return chatResponse, nil
+}
+
+// messagesToChatCompletionParams converts our generic Message slice into Chat Completions
+// messages. Consecutive tool calls are grouped into a single assistant message, which is
+// how the API expects parallel calls to be replayed.
+func messagesToChatCompletionParams(messages []types.Message) []openai.ChatCompletionMessageParamUnion {
+	params := make([]openai.ChatCompletionMessageParamUnion, 0, len(messages))
+
+	for _, m := range messages {
+		switch m.Role {
+
+		case types.MessageRoleSystem:
+			params = append(params, openai.SystemMessage(m.Content))
+
+		case types.MessageRoleUser:
+			if len(m.Parts) > 0 {
+				params = append(params, openai.UserMessage(partsToChatCompletionContent(m.Parts)))
+			} else {
+				params = append(params, openai.UserMessage(m.Content))
+			}
+
+		case types.MessageRoleAssistant:
+			switch {
+			case m.IsReasoning():
+				// Reasoning is provider specific and can't be replayed here.
+				continue
+
+			case m.ToolCall != nil:
+				call := openai.ChatCompletionMessageToolCallParam{
+					ID: m.ToolCall.CallID,
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{
+						Name:      m.ToolCall.Name,
+						Arguments: toolArguments(m.ToolCall),
+					},
+				}
+
+				last := len(params) - 1
+				if last >= 0 && params[last].OfAssistant != nil && len(params[last].OfAssistant.ToolCalls) > 0 {
+					params[last].OfAssistant.ToolCalls = append(params[last].OfAssistant.ToolCalls, call)
+					continue
+				}
+
+				params = append(params, openai.ChatCompletionMessageParamUnion{
+					OfAssistant: &openai.ChatCompletionAssistantMessageParam{
+						ToolCalls: []openai.ChatCompletionMessageToolCallParam{call},
+					},
+				})
+
+			default:
+				if strings.TrimSpace(m.Content) != "" {
+					params = append(params, openai.AssistantMessage(m.Content))
+				}
+			}
+
+		case types.MessageRoleTool:
+			// CRITICAL: tool messages must reference the tool_call id from the previous assistant message
+			params = append(params, openai.ToolMessage(m.Content, m.ToolCallID))
+
+		default:
+			// ignore or handle other roles
+		}
+	}
+
+	return params
+}
+
+// partsToChatCompletionContent converts multimodal content into Chat Completions
+// content parts. Images go as image_url, PDFs as file data and text files inline.
+// Chat Completions can't reference files by URL, so those are only mentioned.
+func partsToChatCompletionContent(parts []types.ContentPart) []openai.ChatCompletionContentPartUnionParam {
+	content := make([]openai.ChatCompletionContentPartUnionParam, 0, len(parts))
+
+	for _, part := range parts {
+		switch {
+		case part.Type == types.ContentPartText:
+			content = append(content, openai.TextContentPart(part.Text))
+
+		case part.Type == types.ContentPartImage:
+			content = append(content, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+				URL: part.DataURL(),
+			}))
+
+		case part.URL != "":
+			content = append(content, openai.TextContentPart(part.Describe()))
+
+		case part.MediaType == "text/plain":
+			text, err := base64.StdEncoding.DecodeString(part.Data)
+			if err != nil {
+				log.Warn().Err(err).Str("filename", part.Filename).Msg("Skipping text file that is not base64 encoded")
+				continue
+			}
+
+			content = append(content, openai.TextContentPart(string(text)))
+
+		default:
+			file := openai.ChatCompletionContentPartFileFileParam{FileData: openai.String(part.DataURL())}
+			if part.Filename != "" {
+				file.Filename = openai.String(part.Filename)
+			}
+
+			content = append(content, openai.FileContentPart(file))
+		}
+	}
+
+	return content
+}
+
+func toolArguments(call *types.ToolCall) string {
+	arguments := strings.TrimSpace(call.Arguments)
+	if arguments == "" && len(call.JSONArguments) > 0 {
+		arguments = string(call.JSONArguments)
+	}
+
+	if arguments == "" {
+		return "{}"
+	}
+
+	return arguments
+}
+
+func buildFunctionTools(toolDefs []types.ToolDefinition) ([]openai.ChatCompletionToolParam, error) {
+	const op = "OpenAICompat.buildFunctionTools"
+
+	var toolParams []openai.ChatCompletionToolParam
+
+	for _, definition := range toolDefs {
+		if definition.Name == "" {
+			return nil, ez.New(op, ez.EINVALID, "tool name is required", nil)
+		}
+
+		function := shared.FunctionDefinitionParam{
+			Name:       definition.Name,
+			Parameters: shared.FunctionParameters(definition.JSONSchema),
+		}
+
+		if definition.Description != "" {
+			function.Description = openai.String(definition.Description)
+		}
+
+		toolParams = append(toolParams, openai.ChatCompletionToolParam{Function: function})
+	}
+
+	return toolParams, nil
+}
@@ -0,0 +1,15 @@
+package openaicompat
+
+import "github.com/vanclief/ez"
+
+// CheckContextWindow uses the catalog entry of the model. The context window depends
+// on how the server was launched and is not exposed through the API, so models that
+// are not in the catalog are never compacted.
+func (compat *OpenAICompat) CheckContextWindow(model string, totalInputTokens, compactAtPercent int) error {
+	err := compat.models.CheckContextWindow(model, totalInputTokens, compactAtPercent)
+	if err != nil {
+		return ez.Wrap("OpenAICompat.CheckContextWindow", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+package openaicompat
+
+import "github.com/vanclief/agent-composer/runtime/types"
+
+// CalculateCost returns total USD cents, unrounded, at the catalog prices.
+// Self-hosted models are usually not in the catalog and cost 0.
+func (compat *OpenAICompat) CalculateCost(model string, usage types.TokenUsage) float64 {
+	return compat.models.Cost(model, usage)
+}
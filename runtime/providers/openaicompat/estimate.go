@@ -0,0 +1,47 @@
+package openaicompat
+
+import (
+	"strings"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/vanclief/agent-composer/runtime/types"
+)
+
+// EstimateInputTokens approximates the prompt size with cl100k_base, local models
+// use a wide range of tokenizers so this is only a ballpark figure.
+func (compat *OpenAICompat) EstimateInputTokens(model string, messages []types.Message) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	tke, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return 0, err
+	}
+
+	return len(tke.Encode(simulatePayload(messages), nil, nil)) + types.EstimatePartTokens(messages), nil
+}
+
+func simulatePayload(messages []types.Message) string {
+	var b strings.Builder
+
+	for _, msg := range messages {
+		b.WriteString(string(msg.Role))
+		b.WriteString(": ")
+
+		switch {
+		case msg.ToolCall != nil:
+			b.WriteString(msg.ToolCall.Name)
+			b.WriteString(" ")
+			b.WriteString(msg.ToolCall.Arguments)
+		case msg.IsReasoning():
+			// Reasoning is not sent back to Chat Completions servers.
+		default:
+			b.WriteString(msg.Content)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package openaicompat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vanclief/ez"
+)
+
+// ListModels returns the IDs of the models served at the base URL.
+func (compat *OpenAICompat) ListModels(ctx context.Context) ([]string, error) {
+	const op = "OpenAICompat.ListModels"
+
+	page, err := compat.client.Models.List(ctx)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to list models from %s", compat.baseURL)
+		return nil, ez.New(op, ez.EUNAVAILABLE, errMsg, err)
+	}
+
+	models := make([]string, 0, len(page.Data))
+	for _, model := range page.Data {
+		models = append(models, model.ID)
+	}
+
+	return models, nil
+}
@@ -0,0 +1,44 @@
+package openaicompat
+
+import (
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/vanclief/agent-composer/runtime/catalog"
+	"github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// OpenAICompat talks to servers that implement the OpenAI Chat Completions API
+// (Ollama, vLLM, llama.cpp, ...) at a configurable base URL.
+type OpenAICompat struct {
+	client  *openai.Client
+	baseURL string
+	models  *catalog.ProviderModels
+}
+
+func New(baseURL, apiKey string, models *catalog.ProviderModels) (types.LLMProvider, error) {
+	const op = "openaicompat.New"
+
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return nil, ez.New(op, ez.EINVALID, "base_url is required", nil)
+	}
+
+	// Retries are handled by the runtime
+	opts := []option.RequestOption{option.WithBaseURL(baseURL), option.WithMaxRetries(0)}
+
+	// Never forward the OpenAI key picked up from the environment to a third-party server.
+	if apiKey != "" {
+		opts = append(opts, option.WithAPIKey(apiKey))
+	} else {
+		opts = append(opts, option.WithHeaderDel("authorization"))
+	}
+
+	client := openai.NewClient(opts...)
+
+	compat := &OpenAICompat{client: &client, baseURL: baseURL, models: models}
+
+	return compat, nil
+}
@@ -0,0 +1,117 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openai/openai-go"
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// reasoningFields are the non-standard delta fields servers use to stream reasoning
+// (vLLM and llama.cpp use reasoning_content, Ollama uses reasoning).
+var reasoningFields = []string{"reasoning_content", "reasoning"}
+
+func (compat *OpenAICompat) ChatStream(ctx context.Context, model string, request *types.ChatRequest, onEvent types.StreamHandler) (types.ChatResponse, error) {
+	const op = "OpenAICompat.ChatStream"
+
+	// Step 1) Create the request
+	params, err := newCompletionParams(model, request)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
+
+	// Ask for a final usage chunk, servers that don't support it just ignore the option
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.Bool(true),
+	}
+
+	// Step 2) Open the stream
+	log.Info().Msg("Doing LLM things...")
+	stream := compat.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+
+	// Tool call deltas only carry the ID and name on their first chunk
+	type toolCall struct{ id, name string }
+	toolCalls := map[int64]toolCall{}
+
+	// Step 3) Forward the deltas while accumulating the full completion
+	for stream.Next() {
+		chunk := stream.Current()
+
+		if !acc.AddChunk(chunk) {
+			return types.ChatResponse{}, ez.New(op, ez.EINTERNAL, "failed to accumulate stream chunk", nil)
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+
+		for _, field := range reasoningFields {
+			reasoning := reasoningDelta(delta, field)
+			if reasoning != "" {
+				onEvent(types.StreamEvent{Type: types.StreamEventReasoningDelta, Delta: reasoning})
+			}
+		}
+
+		if delta.Content != "" {
+			onEvent(types.StreamEvent{Type: types.StreamEventTextDelta, Delta: delta.Content})
+		}
+
+		for _, call := range delta.ToolCalls {
+			known := toolCalls[call.Index]
+			if call.ID != "" {
+				known.id = call.ID
+			}
+			if call.Function.Name != "" {
+				known.name = call.Function.Name
+			}
+			toolCalls[call.Index] = known
+
+			if call.Function.Arguments == "" {
+				continue
+			}
+
+			onEvent(types.StreamEvent{
+				Type:       types.StreamEventToolCallDelta,
+				Delta:      call.Function.Arguments,
+				ToolCallID: known.id,
+				ToolName:   known.name,
+			})
+		}
+	}
+
+	err = stream.Err()
+	if err != nil {
+		providerErr := providerError(err)
+		return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), "Chat Completions API stream failed", providerErr)
+	}
+
+	chatResponse, err := compat.toChatResponse(model, &acc.ChatCompletion)
+	if err != nil {
+		return types.ChatResponse{}, ez.Wrap(op, err)
+	}
+
+	return chatResponse, nil
+}
+
+func reasoningDelta(delta openai.ChatCompletionChunkChoiceDelta, field string) string {
+	raw, ok := delta.JSON.ExtraFields[field]
+	if !ok || !raw.Valid() {
+		return ""
+	}
+
+	var text string
+	err := json.Unmarshal([]byte(raw.Raw()), &text)
+	if err != nil {
+		return ""
+	}
+
+	return text
+}
@@ -0,0 +1,30 @@
+package openaicompat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/vanclief/ez"
+)
+
+func (compat *OpenAICompat) ValidateModel(ctx context.Context, model string) error {
+	const op = "OpenAICompat.ValidateModel"
+
+	if model == "" {
+		return ez.New(op, ez.EINVALID, "model is required", nil)
+	}
+
+	// Not every server implements GET /models/{id}, but they all list models.
+	models, err := compat.ListModels(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if !slices.Contains(models, model) {
+		errMsg := fmt.Sprintf("model %s is not served by %s", model, compat.baseURL)
+		return ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	return nil
+}
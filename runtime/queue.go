@@ -0,0 +1,429 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+const (
+	defaultWorkerConcurrency = 4
+	defaultMaxAttempts       = 3
+
+	// Orders in which workers pick up pending jobs
+	jobOrderingPriority = "priority"
+	jobOrderingFIFO     = "fifo"
+
+	// jobPollInterval is how often an idle worker looks for pending jobs. Jobs
+	// enqueued by the same process wake the worker right away.
+	jobPollInterval = 2 * time.Second
+	// jobTimeout bounds a single run of a conversation.
+	jobTimeout = time.Hour
+
+	// retryBaseDelay and retryMaxDelay bound the exponential backoff between attempts.
+	retryBaseDelay = 15 * time.Second
+	retryMaxDelay  = 10 * time.Minute
+)
+
+var (
+	// errConversationCanceled is the cancel cause of runs stopped through the API.
+	errConversationCanceled = errors.New("conversation canceled")
+	// errLeaseLost is the cancel cause of runs whose job was taken over by another worker.
+	errLeaseLost = errors.New("job lease lost")
+)
+
+// EnqueueConversation appends the prompt and its attachments to the conversation
+// and queues a job to run it. An empty prompt continues from the persisted messages. Jobs with a
+// higher priority are claimed first.
+func (rt *Runtime) EnqueueConversation(ctx context.Context, conversation *agent.Conversation, prompt string, attachments []types.ContentPart, priority int) (*agent.ConversationJob, error) {
+	const op = "runtime.EnqueueConversation"
+
+	job, err := agent.NewConversationJob(conversation, priority, rt.workers.MaxAttempts)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	err = rt.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := agent.GetActiveConversationJob(ctx, tx, conversation.ID)
+		if err == nil {
+			errMsg := fmt.Sprintf("conversation with ID %s is already queued or running", conversation.ID)
+			return ez.New(op, ez.ECONFLICT, errMsg, nil)
+		} else if ez.ErrorCode(err) != ez.ENOTFOUND {
+			return err
+		}
+
+		if prompt != "" || len(attachments) > 0 {
+			conversation.Messages = append(conversation.Messages, *types.NewUserMessageWithParts(prompt, attachments))
+		}
+		conversation.Status = agent.ConversationStatusQueued
+		conversation.StatusReason = ""
+
+		err = conversation.Update(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		return job.Insert(ctx, tx)
+	})
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	rt.publishConversationEvent(ConversationEvent{
+		ConversationID: conversation.ID,
+		Type:           ConversationEventStatus,
+		Status:         conversation.Status,
+	})
+
+	rt.wake()
+
+	return job, nil
+}
+
+// RunWorker claims queued conversations and runs up to the configured concurrency
+// of them at once, blocking until ctx is canceled. Any number of processes can run
+// workers against the same database. On shutdown the runs in progress are stopped
+// and handed back to the queue according to their recovery policy.
+func (rt *Runtime) RunWorker(ctx context.Context) {
+	log.Info().
+		Str("worker_id", rt.workerID).
+		Int("concurrency", rt.workers.Concurrency).
+		Msg("Starting conversation worker")
+
+	slots := make(chan struct{}, rt.workers.Concurrency)
+	var wg sync.WaitGroup
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Fill every free slot with a pending job
+		for rt.claimSlot(slots) {
+			job, err := agent.ClaimConversationJob(ctx, rt.db, rt.workerID, time.Now().UTC().Add(jobLease), rt.claimOptions())
+			if err != nil || job == nil {
+				<-slots
+
+				if err != nil && ctx.Err() == nil {
+					log.Error().Err(err).Msg("Failed to claim conversation job")
+				}
+				break
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					<-slots
+					rt.wake()
+				}()
+
+				rt.runJob(ctx, job)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		case <-rt.wakeup:
+		}
+	}
+}
+
+// claimOptions returns the concurrency limits and ordering jobs are claimed with.
+// Per spec limits are stored on the specs themselves.
+func (rt *Runtime) claimOptions() agent.JobClaimOptions {
+	return agent.JobClaimOptions{
+		MaxRunning:           rt.workers.MaxConcurrent,
+		MaxRunningPerSession: rt.workers.MaxPerSession,
+		FIFO:                 rt.workers.Ordering == jobOrderingFIFO,
+	}
+}
+
+func (rt *Runtime) claimSlot(slots chan struct{}) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// wake makes the worker of this process look for pending jobs right away.
+func (rt *Runtime) wake() {
+	select {
+	case rt.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// runJob executes a claimed job and records its outcome on both the job and the
+// conversation.
+func (rt *Runtime) runJob(ctx context.Context, job *agent.ConversationJob) {
+	logger := log.With().
+		Str("job_id", job.ID.String()).
+		Str("conversation_id", job.ConversationID.String()).
+		Int("attempt", job.Attempts).
+		Logger()
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	jobCtx, cancelTimeout := context.WithTimeout(jobCtx, jobTimeout)
+	defer cancelTimeout()
+
+	// The conversation can be canceled through the API while it runs
+	untrack := rt.trackJob(job.ConversationID, cancel)
+	defer untrack()
+
+	var ci *ConversationInstance
+	var runErr error
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error().Any("panic", r).Bytes("stack", debug.Stack()).Msg("Conversation job panic")
+				runErr = ez.New("runtime.runJob", ez.EINTERNAL, fmt.Sprintf("panic: %v", r), nil)
+			}
+		}()
+
+		ci, runErr = rt.NewConversationInstance(jobCtx, job.ConversationID)
+		if runErr != nil {
+			return
+		}
+		ci.priority = job.Priority
+
+		stopHeartbeat := rt.keepAlive(jobCtx, ci, job, cancel)
+		defer stopHeartbeat()
+
+		runErr = rt.runConversationInstance(jobCtx, ci)
+	}()
+
+	// Record the outcome even though the job context may be done
+	pCtx := context.WithoutCancel(ctx)
+
+	err := rt.finishJob(pCtx, jobCtx, job, ci, runErr)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to record conversation job outcome")
+		return
+	}
+
+	if runErr != nil {
+		logger.Error().Err(runErr).Str("job_status", string(job.Status)).Msg("Conversation job did not succeed")
+		return
+	}
+
+	logger.Info().Msg("Conversation job finished")
+}
+
+// finishJob decides what happens to the job and its conversation after a run:
+// successful and canceled runs are final, interrupted runs follow the recovery
+// policy, and failed runs are retried with backoff while attempts remain.
+func (rt *Runtime) finishJob(ctx, jobCtx context.Context, job *agent.ConversationJob, ci *ConversationInstance, runErr error) error {
+	const op = "runtime.finishJob"
+
+	cause := context.Cause(jobCtx)
+
+	// Another worker owns the job now and will record its outcome
+	if errors.Is(cause, errLeaseLost) {
+		return nil
+	}
+
+	conversation := conversationOf(ci)
+	if conversation == nil {
+		var err error
+		conversation, err = agent.GetConversationByID(ctx, rt.db, job.ConversationID)
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+	}
+
+	now := time.Now().UTC()
+
+	// Runs stopped by the API or lease loss carry their own cause, a plain
+	// cancellation means the worker is shutting down
+	shutdown := runErr != nil && errors.Is(cause, context.Canceled)
+
+	switch {
+	case runErr == nil:
+		job.Status = agent.JobStatusSucceeded
+
+	case errors.Is(cause, errConversationCanceled):
+		job.Status = agent.JobStatusCanceled
+		conversation.Status = agent.ConversationStatusCanceled
+		conversation.Messages = closeDanglingToolCalls(conversation.Messages, canceledToolResult)
+
+	case shutdown && conversation.RecoveryPolicy == agent.RecoveryPolicyResume:
+		// Hand the run to the next worker without spending an attempt
+		job.Status = agent.JobStatusPending
+		job.Attempts--
+		job.RunAt = now
+		conversation.Status = agent.ConversationStatusQueued
+		conversation.Messages = closeDanglingToolCalls(conversation.Messages, interruptedToolResult)
+
+	case shutdown:
+		job.Status = agent.JobStatusFailed
+		conversation.Status = agent.ConversationStatusFailed
+		conversation.StatusReason = orphanedReason
+		conversation.Messages = closeDanglingToolCalls(conversation.Messages, interruptedToolResult)
+
+	case retryable(jobCtx, runErr) && job.Attempts < job.MaxAttempts:
+		job.Status = agent.JobStatusPending
+		job.RunAt = now.Add(retryDelay(job.Attempts, runErr))
+		conversation.Status = agent.ConversationStatusQueued
+		conversation.StatusReason = fmt.Sprintf("attempt %d of %d failed, retrying: %s", job.Attempts, job.MaxAttempts, ez.ErrorMessage(runErr))
+		conversation.Messages = closeDanglingToolCalls(conversation.Messages, interruptedToolResult)
+
+	default:
+		job.Status = agent.JobStatusFailed
+		conversation.Status = agent.ConversationStatusFailed
+		conversation.StatusReason = ez.ErrorMessage(runErr)
+		if errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+			conversation.StatusReason = fmt.Sprintf("run exceeded the maximum duration of %s", jobTimeout)
+		}
+		conversation.Messages = closeDanglingToolCalls(conversation.Messages, interruptedToolResult)
+	}
+
+	if runErr != nil {
+		job.LastError = runErr.Error()
+	}
+
+	if job.Status.IsActive() {
+		job.LockedBy = ""
+		job.LeaseExpiresAt = nil
+	} else {
+		job.FinishedAt = &now
+	}
+
+	err := rt.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := job.UpdateLeased(ctx, tx, rt.workerID)
+		if err != nil {
+			return err
+		}
+
+		return conversation.Update(ctx, tx)
+	})
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if ci != nil {
+		ci.emitStatus()
+	} else {
+		rt.publishConversationEvent(ConversationEvent{
+			ConversationID: conversation.ID,
+			Type:           ConversationEventStatus,
+			Status:         conversation.Status,
+		})
+	}
+
+	return nil
+}
+
+// keepAlive renews the lease of the job, and the heartbeat of its conversation,
+// until the returned function is called. The run is canceled when a cancellation
+// is requested from another process or the lease is lost.
+func (rt *Runtime) keepAlive(ctx context.Context, ci *ConversationInstance, job *agent.ConversationJob, cancel context.CancelCauseFunc) func() {
+	err := ci.Heartbeat(ctx, rt.db)
+	if err != nil {
+		log.Warn().Err(err).Str("conversation_id", ci.ID.String()).Msg("Failed to write conversation heartbeat")
+	}
+
+	heartbeatCtx, stop := context.WithCancel(ctx)
+
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-heartbeatCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			cancelRequested, err := job.RenewLease(heartbeatCtx, rt.db, rt.workerID, time.Now().UTC().Add(jobLease))
+			switch {
+			case heartbeatCtx.Err() != nil:
+				return
+			case ez.ErrorCode(err) == ez.ECONFLICT:
+				log.Warn().Err(err).Str("conversation_id", ci.ID.String()).Msg("Lost the lease of the conversation job")
+				cancel(errLeaseLost)
+				return
+			case err != nil:
+				log.Warn().Err(err).Str("conversation_id", ci.ID.String()).Msg("Failed to renew conversation job lease")
+			case cancelRequested:
+				cancel(errConversationCanceled)
+				return
+			}
+
+			err = ci.Heartbeat(heartbeatCtx, rt.db)
+			if err != nil && heartbeatCtx.Err() == nil {
+				log.Warn().Err(err).Str("conversation_id", ci.ID.String()).Msg("Failed to write conversation heartbeat")
+			}
+		}
+	}()
+
+	return stop
+}
+
+// retryable reports whether a failed run may succeed if attempted again. Errors
+// caused by the conversation itself, e.g. invalid requests or exhausted steps,
+// would fail the same way.
+func retryable(jobCtx context.Context, err error) bool {
+	if errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+
+	switch ez.ErrorCode(err) {
+	case ez.EINTERNAL, ez.EUNAVAILABLE:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryDelay returns the exponential backoff before the next attempt, or the wait
+// requested by the provider that failed the run when it is longer.
+func retryDelay(attempt int, err error) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	providerErr, ok := types.AsProviderError(err)
+	if ok && providerErr.RetryAfter > delay {
+		delay = providerErr.RetryAfter
+	}
+
+	return min(delay, retryMaxDelay)
+}
+
+func conversationOf(ci *ConversationInstance) *agent.Conversation {
+	if ci == nil {
+		return nil
+	}
+
+	return ci.Conversation
+}
+
+func newWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), uuid.NewString()[:8])
+}
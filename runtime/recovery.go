@@ -0,0 +1,248 @@
+package runtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+const (
+	// heartbeatInterval is how often a worker renews the lease of the jobs it runs.
+	heartbeatInterval = 10 * time.Second
+	// jobLease is how long a run may go without a heartbeat before it is considered
+	// dead and gets recovered.
+	jobLease = time.Minute
+)
+
+// interruptedToolResult is recorded for tool calls that were in flight when the
+// process running the conversation stopped.
+const interruptedToolResult = `{"error":"interrupted","message":"The tool call was interrupted before it finished."}`
+
+// orphanedReason is stored on conversations that were failed by the recovery.
+const orphanedReason = "run was interrupted: the process executing it stopped before it finished"
+
+// RecoverConversations finds runs whose worker stopped without finishing them,
+// e.g. because the process crashed, and applies the recovery policy their
+// conversation was created with: they are either failed with a reason or queued
+// again to resume from their persisted messages.
+func (rt *Runtime) RecoverConversations(ctx context.Context) error {
+	const op = "runtime.RecoverConversations"
+
+	err := rt.recoverExpiredJobs(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	err = rt.recoverOrphanedConversations(ctx)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// recoverExpiredJobs handles the running jobs whose lease expired.
+func (rt *Runtime) recoverExpiredJobs(ctx context.Context) error {
+	const op = "runtime.recoverExpiredJobs"
+
+	var recovered []*agent.Conversation
+
+	err := rt.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		jobs, err := agent.LockExpiredConversationJobs(ctx, tx, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		for _, job := range jobs {
+			conversation, err := agent.GetConversationByID(ctx, tx, job.ConversationID)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now().UTC()
+			done := answered(conversation.Messages)
+
+			job.LockedBy = ""
+			job.LeaseExpiresAt = nil
+			job.LastError = orphanedReason
+			conversation.Messages = closeDanglingToolCalls(conversation.Messages, interruptedToolResult)
+
+			switch {
+			case done:
+				// The model already answered, the run only missed its final update
+				job.Status = agent.JobStatusSucceeded
+				job.FinishedAt = &now
+				conversation.Status = agent.ConversationStatusSucceeded
+			case job.CancelRequested:
+				job.Status = agent.JobStatusCanceled
+				job.FinishedAt = &now
+				conversation.Status = agent.ConversationStatusCanceled
+			case conversation.RecoveryPolicy == agent.RecoveryPolicyResume && job.Attempts < job.MaxAttempts:
+				job.Status = agent.JobStatusPending
+				job.RunAt = now
+				conversation.Status = agent.ConversationStatusQueued
+			default:
+				job.Status = agent.JobStatusFailed
+				job.FinishedAt = &now
+				conversation.Status = agent.ConversationStatusFailed
+				conversation.StatusReason = orphanedReason
+			}
+
+			err = job.Update(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = conversation.Update(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			log.Info().
+				Str("conversation_id", conversation.ID.String()).
+				Str("job_id", job.ID.String()).
+				Str("recovery_policy", string(conversation.RecoveryPolicy)).
+				Str("status", string(conversation.Status)).
+				Msg("Recovered conversation job with an expired lease")
+
+			recovered = append(recovered, conversation)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	for _, conversation := range recovered {
+		rt.publishConversationEvent(ConversationEvent{
+			ConversationID: conversation.ID,
+			Type:           ConversationEventStatus,
+			Status:         conversation.Status,
+		})
+	}
+
+	if len(recovered) > 0 {
+		rt.wake()
+	}
+
+	return nil
+}
+
+// recoverOrphanedConversations handles queued or running conversations left
+// without a job, e.g. by a process that stopped before it could enqueue them.
+func (rt *Runtime) recoverOrphanedConversations(ctx context.Context) error {
+	const op = "runtime.recoverOrphanedConversations"
+
+	staleBefore := time.Now().UTC().Add(-jobLease)
+
+	conversations, err := agent.GetOrphanedConversations(ctx, rt.db, staleBefore)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	for _, conversation := range conversations {
+		// Another process may be recovering the same conversation
+		claimed, err := conversation.ClaimLease(ctx, rt.db, staleBefore)
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+
+		if !claimed {
+			continue
+		}
+
+		logger := log.With().
+			Str("conversation_id", conversation.ID.String()).
+			Str("status", string(conversation.Status)).
+			Str("recovery_policy", string(conversation.RecoveryPolicy)).
+			Logger()
+
+		if conversation.RecoveryPolicy == agent.RecoveryPolicyResume {
+			err = rt.resumeOrphanedConversation(ctx, conversation)
+			if err == nil {
+				logger.Info().Msg("Resumed orphaned conversation")
+				continue
+			}
+
+			logger.Error().Err(err).Msg("Failed to resume orphaned conversation")
+		}
+
+		err = rt.failOrphanedConversation(ctx, conversation)
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+
+		logger.Info().Msg("Marked orphaned conversation as failed")
+	}
+
+	return nil
+}
+
+func (rt *Runtime) failOrphanedConversation(ctx context.Context, conversation *agent.Conversation) error {
+	const op = "runtime.failOrphanedConversation"
+
+	conversation.Status = agent.ConversationStatusFailed
+	conversation.StatusReason = orphanedReason
+	conversation.Messages = closeDanglingToolCalls(conversation.Messages, interruptedToolResult)
+
+	err := conversation.Update(ctx, rt.db)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	rt.publishConversationEvent(ConversationEvent{
+		ConversationID: conversation.ID,
+		Type:           ConversationEventStatus,
+		Status:         conversation.Status,
+	})
+
+	return nil
+}
+
+func (rt *Runtime) resumeOrphanedConversation(ctx context.Context, conversation *agent.Conversation) error {
+	const op = "runtime.resumeOrphanedConversation"
+
+	// If the model already answered, the run only missed its final update
+	if answered(conversation.Messages) {
+		conversation.Status = agent.ConversationStatusSucceeded
+
+		err := conversation.Update(ctx, rt.db)
+		if err != nil {
+			return ez.Wrap(op, err)
+		}
+
+		rt.publishConversationEvent(ConversationEvent{
+			ConversationID: conversation.ID,
+			Type:           ConversationEventStatus,
+			Status:         conversation.Status,
+		})
+
+		return nil
+	}
+
+	conversation.Messages = closeDanglingToolCalls(conversation.Messages, interruptedToolResult)
+
+	// An empty prompt continues from the persisted messages
+	_, err := rt.EnqueueConversation(ctx, conversation, "", nil, 0)
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// answered reports whether the last message is a final assistant answer.
+func answered(messages []types.Message) bool {
+	if len(messages) == 0 {
+		return false
+	}
+
+	last := messages[len(messages)-1]
+	return last.Role == types.MessageRoleAssistant && last.ToolCall == nil && !last.IsReasoning()
+}
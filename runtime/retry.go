@@ -0,0 +1,295 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/core/controller"
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+const (
+	defaultProviderRetries  = 4
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 30 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ProviderRetry describes a failed provider call that is about to be tried again.
+// Anything streamed by the failed attempt should be discarded.
+type ProviderRetry struct {
+	Attempt    int              `json:"attempt"`
+	Class      types.ErrorClass `json:"class"`
+	StatusCode int              `json:"status_code,omitempty"`
+	DelayMs    int64            `json:"delay_ms"`
+	Error      string           `json:"error"`
+}
+
+// retryPolicy is the resolved form of controller.ProviderRetryConfig.
+type retryPolicy struct {
+	maxRetries       int
+	baseDelay        time.Duration
+	maxDelay         time.Duration
+	breakerThreshold int
+	breakerCooldown  time.Duration
+}
+
+func newRetryPolicy(cfg controller.ProviderRetryConfig) retryPolicy {
+	policy := retryPolicy{
+		maxRetries:       cfg.MaxRetries,
+		baseDelay:        time.Duration(cfg.BaseDelay) * time.Millisecond,
+		maxDelay:         time.Duration(cfg.MaxDelay) * time.Second,
+		breakerThreshold: cfg.BreakerThreshold,
+		breakerCooldown:  time.Duration(cfg.BreakerCooldown) * time.Second,
+	}
+
+	if policy.maxRetries <= 0 {
+		policy.maxRetries = defaultProviderRetries
+	}
+
+	if policy.baseDelay <= 0 {
+		policy.baseDelay = defaultRetryBaseDelay
+	}
+
+	if policy.maxDelay <= 0 {
+		policy.maxDelay = defaultRetryMaxDelay
+	}
+
+	if policy.breakerThreshold <= 0 {
+		policy.breakerThreshold = defaultBreakerThreshold
+	}
+
+	if policy.breakerCooldown <= 0 {
+		policy.breakerCooldown = defaultBreakerCooldown
+	}
+
+	return policy
+}
+
+// delay returns the jittered exponential backoff before the given retry, or the
+// wait requested by the provider when it is longer.
+func (p retryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	backoff := p.baseDelay
+	for i := 0; i < attempt && backoff < p.maxDelay; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, p.maxDelay)
+
+	// Equal jitter keeps at least half the backoff while spreading out the retries
+	// of conversations that failed together
+	half := backoff / 2
+	delay := half + rand.N(half+1)
+
+	return max(delay, retryAfter)
+}
+
+// circuitBreaker stops calls to a provider/model after too many consecutive
+// transient failures. Once the cooldown passes a single call is let through, and
+// its outcome closes or reopens the breaker.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow returns how much longer the breaker stays open, 0 if the call can go ahead.
+func (b *circuitBreaker) allow() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return 0
+	}
+
+	remaining := time.Until(b.openUntil)
+	if remaining > 0 {
+		return remaining
+	}
+
+	if b.probing {
+		return b.cooldown
+	}
+
+	b.probing = true
+
+	return 0
+}
+
+// record counts the outcome of a call that was allowed through.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// release lets the next call probe the provider after a call whose outcome says
+// nothing about its health, e.g. one that was canceled or rejected as invalid.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// breaker returns the circuit breaker shared by every call to the provider/model.
+func (rt *Runtime) breaker(provider agent.LLMProvider, baseURL, model string) *circuitBreaker {
+	key := fmt.Sprintf("%s|%s|%s", provider, baseURL, model)
+
+	rt.breakersMu.Lock()
+	defer rt.breakersMu.Unlock()
+
+	b, ok := rt.breakers[key]
+	if !ok {
+		b = &circuitBreaker{threshold: rt.retry.breakerThreshold, cooldown: rt.retry.breakerCooldown}
+		rt.breakers[key] = b
+	}
+
+	return b
+}
+
+// resilientProvider is the middleware around the provider calls of a conversation.
+// Transient failures are retried with backoff, honoring Retry-After, and calls to
+// a provider/model with an open circuit breaker fail right away.
+type resilientProvider struct {
+	types.LLMProvider
+	policy    retryPolicy
+	breaker   func(model string) *circuitBreaker
+	onRetry   func(ProviderRetry)
+	onFailure func(*types.ProviderError)
+}
+
+// withRetries wraps the provider of a conversation instance, recording the retries
+// and the class of the last failure on the conversation.
+func (rt *Runtime) withRetries(ci *ConversationInstance, ref agent.ModelRef, provider types.LLMProvider) *resilientProvider {
+	return &resilientProvider{
+		LLMProvider: provider,
+		policy:      rt.retry,
+		breaker: func(model string) *circuitBreaker {
+			return rt.breaker(ref.Provider, ref.BaseURL, model)
+		},
+		onRetry: func(retry ProviderRetry) {
+			ci.ProviderRetries++
+			ci.emit(ConversationEvent{Type: ConversationEventRetry, Retry: &retry})
+		},
+		onFailure: func(providerErr *types.ProviderError) {
+			ci.ProviderErrorClass = providerErr.Class
+		},
+	}
+}
+
+func (p *resilientProvider) Chat(ctx context.Context, model string, request *types.ChatRequest) (types.ChatResponse, error) {
+	return p.call(ctx, model, func() (types.ChatResponse, error) {
+		return p.LLMProvider.Chat(ctx, model, request)
+	})
+}
+
+func (p *resilientProvider) ChatStream(ctx context.Context, model string, request *types.ChatRequest, onEvent types.StreamHandler) (types.ChatResponse, error) {
+	streamer, ok := p.LLMProvider.(types.StreamingLLMProvider)
+	if !ok {
+		return p.Chat(ctx, model, request)
+	}
+
+	return p.call(ctx, model, func() (types.ChatResponse, error) {
+		return streamer.ChatStream(ctx, model, request, onEvent)
+	})
+}
+
+func (p *resilientProvider) call(ctx context.Context, model string, fn func() (types.ChatResponse, error)) (types.ChatResponse, error) {
+	const op = "runtime.resilientProvider.call"
+
+	breaker := p.breaker(model)
+
+	for attempt := 0; ; attempt++ {
+		open := breaker.allow()
+		if open > 0 {
+			providerErr := &types.ProviderError{
+				Class:      types.ErrorClassCircuitOpen,
+				RetryAfter: open,
+				Err:        fmt.Errorf("too many consecutive failures calling %s", model),
+			}
+			p.onFailure(providerErr)
+
+			errMsg := fmt.Sprintf("Calls to %s are paused for %s after repeated failures", model, open.Round(time.Second))
+			return types.ChatResponse{}, ez.New(op, providerErr.Class.Code(), errMsg, providerErr)
+		}
+
+		response, err := fn()
+		if err == nil {
+			breaker.record(false)
+			return response, nil
+		}
+
+		providerErr, ok := types.AsProviderError(err)
+		if !ok || ctx.Err() != nil {
+			// The request never reached the provider, e.g. it could not be built, or it was canceled
+			breaker.release()
+			return types.ChatResponse{}, err
+		}
+
+		// Only transient failures say something about the health of the provider, other
+		// errors (invalid requests, auth) leave the breaker as it was
+		if providerErr.Class.Transient() {
+			breaker.record(true)
+		} else {
+			breaker.release()
+		}
+
+		if !providerErr.Class.Transient() || attempt >= p.policy.maxRetries {
+			p.onFailure(providerErr)
+			return types.ChatResponse{}, err
+		}
+
+		// Waits longer than the policy allows are left to the job queue
+		delay := p.policy.delay(attempt, providerErr.RetryAfter)
+		if delay > p.policy.maxDelay {
+			p.onFailure(providerErr)
+			return types.ChatResponse{}, err
+		}
+
+		log.Warn().
+			Err(err).
+			Str("model", model).
+			Str("class", string(providerErr.Class)).
+			Int("attempt", attempt+1).
+			Dur("delay", delay).
+			Msg("Retrying provider call")
+
+		p.onRetry(ProviderRetry{
+			Attempt:    attempt + 1,
+			Class:      providerErr.Class,
+			StatusCode: providerErr.StatusCode,
+			DelayMs:    delay.Milliseconds(),
+			Error:      providerErr.Error(),
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			p.onFailure(providerErr)
+			return types.ChatResponse{}, err
+		case <-timer.C:
+		}
+	}
+}
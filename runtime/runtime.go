@@ -2,24 +2,37 @@ package runtime
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"strings"
-
-	"github.com/openai/openai-go"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 
+	"github.com/google/uuid"
 	"github.com/vanclief/agent-composer/core/controller"
 	"github.com/vanclief/agent-composer/models/agent"
-	"github.com/vanclief/compose/components/scheduler"
+	"github.com/vanclief/agent-composer/runtime/catalog"
 	"github.com/vanclief/compose/drivers/databases/relational"
 	"github.com/vanclief/ez"
 )
 
 type Runtime struct {
-	rootCtx   context.Context
-	db        *relational.DB
-	scheduler *scheduler.Scheduler
-	openai    *openai.Client
+	db          *relational.DB
+	providersMu sync.RWMutex
+	providers   map[agent.LLMProvider]ProviderFactory
+	events      *eventBroker
+	relay       *eventRelay // Events waiting to be relayed, see RelayEvents
+	relaying    atomic.Bool
+	jobsMu      sync.Mutex
+	jobs        map[uuid.UUID]*runningJob
+	workers     controller.WorkersConfig
+	workerID    string
+	wakeup      chan struct{}
+	retry       retryPolicy
+	breakersMu  sync.Mutex
+	breakers    map[string]*circuitBreaker
+	catalog     *catalog.Catalog
+	modelLists  modelLists
+	// loopDetector creates the loop detector of each run, see SetLoopDetector
+	loopDetector LoopDetectorFactory
 }
 
 type hookSub struct {
@@ -27,59 +40,63 @@ type hookSub struct {
 	unsubscribe func() error
 }
 
-func New(rootCtx context.Context, ctrl *controller.Controller, sch *scheduler.Scheduler) (*Runtime, error) {
+func New(ctrl *controller.Controller) (*Runtime, error) {
 	const op = "runtime.New"
 
 	if ctrl == nil {
 		return nil, ez.Root(op, ez.EINTERNAL, "Controller reference is nil")
 	}
 
-	// TODO: Should dinamically chose which LLM Providers to initialize
-	// for now, only OpenAI is supported
-
-	rt := &Runtime{
-		rootCtx:   rootCtx,
-		db:        ctrl.DB,
-		scheduler: sch,
-	}
-
-	err := rt.SetOpenAIClient()
+	// Model prices, context windows and capabilities, overridable from the config dir
+	models, err := catalog.Load(filepath.Join(ctrl.ConfigDir, catalog.FileName))
 	if err != nil {
 		return nil, ez.Wrap(op, err)
 	}
 
-	return rt, nil
-}
-
-func (rt *Runtime) SetOpenAIClient() error {
-	const op = "runtime.SetOpenAIClient"
-
-	apiKey := strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
-	if apiKey == "" {
-		return ez.New(op, ez.EINVALID, "missing env var OPENAI_API_KEY", nil)
+	rt := &Runtime{
+		db:           ctrl.DB,
+		providers:    make(map[agent.LLMProvider]ProviderFactory),
+		events:       newEventBroker(),
+		relay:        newEventRelay(),
+		jobs:         make(map[uuid.UUID]*runningJob),
+		workers:      ctrl.Config.Workers,
+		workerID:     newWorkerID(),
+		wakeup:       make(chan struct{}, 1),
+		retry:        newRetryPolicy(ctrl.Config.Providers.Retry),
+		breakers:     make(map[string]*circuitBreaker),
+		catalog:      models,
+		modelLists:   modelLists{lists: make(map[string]modelList)},
+		loopDetector: NewCycleDetector,
 	}
 
-	client := openai.NewClient()
-
-	rt.openai = &client
-
-	return nil
-}
+	if rt.workers.Concurrency <= 0 {
+		rt.workers.Concurrency = defaultWorkerConcurrency
+	}
 
-func (rt *Runtime) ValidateModel(ctx context.Context, provider agent.LLMProvider, model string) error {
-	const op = "ChatGPT.ValidateModel"
+	if rt.workers.MaxAttempts <= 0 {
+		rt.workers.MaxAttempts = defaultMaxAttempts
+	}
 
-	if model == "" {
-		return ez.New(op, ez.EINVALID, "model is required", nil)
+	switch rt.workers.Ordering {
+	case "":
+		rt.workers.Ordering = jobOrderingPriority
+	case jobOrderingPriority, jobOrderingFIFO:
+	default:
+		return nil, ez.New(op, ez.EINVALID, "workers.ordering must be either priority or fifo", nil)
 	}
 
-	// Uses the official SDK's Models service (Get) to verify the model ID.
-	// Any 4xx/5xx from the API bubbles up here.
-	_, err := rt.openai.Models.Get(ctx, model)
+	// Provider clients are created on first use, a missing API key only fails the
+	// specs that use that provider
+	err = rt.registerBuiltinProviders(ctrl.Config.Providers)
 	if err != nil {
-		errMsg := fmt.Sprintf("ChatGPT model %s does not exist", model)
-		return ez.New(op, ez.EINVALID, errMsg, err)
+		return nil, ez.Wrap(op, err)
 	}
 
-	return nil
+	return rt, nil
+}
+
+// Catalog returns the model catalog. Programs embedding the runtime can use it to
+// add models or override their prices at runtime.
+func (rt *Runtime) Catalog() *catalog.Catalog {
+	return rt.catalog
 }
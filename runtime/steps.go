@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/models/agent"
+	"github.com/vanclief/agent-composer/models/hook"
+	types "github.com/vanclief/agent-composer/runtime/types"
+)
+
+// startLLMStep begins the record of a model call of the current step.
+func (ci *ConversationInstance) startLLMStep(estimatedInputTokens int) *agent.ConversationStep {
+	step := agent.NewConversationStep(ci.ID, ci.step, agent.StepKindLLMCall)
+	step.EstimatedInputTokens = estimatedInputTokens
+
+	return step
+}
+
+// finishLLMStep completes the record of a model call with the model that served
+// it, which can differ from the one it started with after a fallback. Its usage is
+// set by recordStepUsage.
+func (ci *ConversationInstance) finishLLMStep(step *agent.ConversationStep, err error) {
+	step.Provider = ci.model.Provider
+	step.Model = ci.model.Model
+
+	step.Finish(err)
+}
+
+// startToolStep begins the record of a tool call of the current step.
+func (ci *ConversationInstance) startToolStep(toolCall *types.ToolCall) *agent.ConversationStep {
+	step := agent.NewConversationStep(ci.ID, ci.step, agent.StepKindToolCall)
+	step.ToolName = toolCall.Name
+	step.ToolCallID = toolCall.CallID
+
+	return step
+}
+
+// finishToolStep completes the record of a tool call with its exit code, when the
+// tool reports one, and the kind of error it failed with.
+func finishToolStep(step *agent.ConversationStep, content string, err error) {
+	toolErr, ok := types.AsToolError(err)
+	if ok {
+		step.ToolError = toolErr.Kind
+		if content == "" {
+			content = toolErr.Output
+		}
+	}
+
+	step.ExitCode = toolExitCode(content)
+	step.Finish(err)
+}
+
+// recordHook adds the outcome of a hook to a step record, if there is one.
+func recordHook(step *agent.ConversationStep, h hook.Hook, result HookResult, err error) {
+	if step == nil {
+		return
+	}
+
+	outcome := agent.StepHook{
+		HookID:    h.ID,
+		EventType: h.EventType,
+		ExitCode:  result.ExitCode,
+		Blocked:   result.ExitCode == 2,
+	}
+
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+
+	step.Hooks = append(step.Hooks, outcome)
+}
+
+// toolExitCode reads the exit_code of tool results shaped like the shell tool's.
+func toolExitCode(content string) *int {
+	var result struct {
+		ExitCode *int `json:"exit_code"`
+	}
+
+	err := json.Unmarshal([]byte(content), &result)
+	if err != nil {
+		return nil
+	}
+
+	return result.ExitCode
+}
+
+// saveStep persists a finished step record. A failed write is only logged, the run
+// doesn't depend on its audit trail.
+func (rt *Runtime) saveStep(ctx context.Context, step *agent.ConversationStep) {
+	// Steps of stopped runs are still recorded
+	err := step.Insert(context.WithoutCancel(ctx), rt.db)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("conversation_id", step.ConversationID.String()).
+			Str("kind", string(step.Kind)).
+			Int("step", step.Step).
+			Msg("Failed to record conversation step")
+	}
+}
@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+)
+
+// repairPrompt asks the model to answer again after an invalid structured output
+const repairPrompt = `Your last answer was rejected, it is %s.
+
+Reply again with only the JSON that matches the required schema, without any other text.`
+
+// checkStructuredOutput validates the final answer of a structured output
+// conversation and stores it as the result. When the answer is invalid and repairs
+// are left, a repair prompt is added and true is returned so the model is asked again.
+func (ci *ConversationInstance) checkStructuredOutput(answer string) (bool, error) {
+	const op = "runtime.ConversationInstance.checkStructuredOutput"
+
+	if !ci.StructuredOutput {
+		return false, nil
+	}
+
+	if ci.outputSchema == nil {
+		schema, err := types.CompileSchema(ci.StructuredOutputSchema)
+		if err != nil {
+			return false, ez.Wrap(op, err)
+		}
+		ci.outputSchema = schema
+	}
+
+	result, err := types.ParseStructuredOutput(ci.outputSchema, answer)
+	if err == nil {
+		ci.Result = result
+		return false, nil
+	}
+
+	if ci.repairs >= ci.StructuredOutputRepairs {
+		errMsg := fmt.Sprintf("structured output is invalid after %d repairs: %s", ci.repairs, ez.ErrorMessage(err))
+		return false, ez.New(op, ez.EINVALID, errMsg, err)
+	}
+
+	ci.repairs++
+
+	log.Warn().
+		Str("Name", ci.AgentName).
+		Str("ID", ci.ID.String()).
+		Str("error", ez.ErrorMessage(err)).
+		Int("repair", ci.repairs).
+		Msg("Structured output is invalid, asking for a repair")
+
+	ci.AddMessage(types.MessageRoleUser, fmt.Sprintf(repairPrompt, ez.ErrorMessage(err)))
+
+	return true, nil
+}
@@ -0,0 +1,287 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vanclief/agent-composer/models/agent"
+	types "github.com/vanclief/agent-composer/runtime/types"
+	"github.com/vanclief/ez"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultMaxParallelToolCalls bounds the tool calls of a step that run at once when
+// the conversation doesn't set its own limit.
+const defaultMaxParallelToolCalls = 4
+
+// duplicateToolCallResult is recorded instead of running a call the anti-loop policy skipped
+const duplicateToolCallResult = `{"error":"duplicate_tool_call","policy":"anti-loop","message":"Duplicate tool call with identical arguments within one step; tool execution skipped."}`
+
+// notRunToolResult is recorded for the calls of a step that never ran because an
+// earlier call stopped the run.
+const notRunToolResult = `{"error":"not_run","message":"The tool call did not run because another call of the step stopped the run."}`
+
+// toolError is the tool result recorded when a call can't run or fails, so the
+// model can correct the call instead of the conversation failing.
+type toolError struct {
+	Error      types.ToolErrorKind `json:"error"`
+	Tool       string              `json:"tool"`
+	Message    string              `json:"message"`
+	Violations []string            `json:"violations,omitempty"` // Schema violations of the arguments
+	Output     string              `json:"output,omitempty"`     // What the tool returned along with the failure
+}
+
+// toolErrorResult encodes the error of a recoverable tool call failure for the transcript.
+func toolErrorResult(toolName string, err error) string {
+	payload := toolError{
+		Error:   types.ToolErrorFailed,
+		Tool:    toolName,
+		Message: ez.ErrorMessage(err),
+	}
+
+	toolErr, ok := types.AsToolError(err)
+	if ok {
+		payload.Error = toolErr.Kind
+		payload.Violations = toolErr.Violations
+		payload.Output = toolErr.Output
+	}
+
+	encoded, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return ez.ErrorMessage(err)
+	}
+
+	return string(encoded)
+}
+
+// maxParallelToolCalls returns how many tool calls of a step may run at once.
+func (ci *ConversationInstance) maxParallelToolCalls() int {
+	if ci.MaxParallelToolCalls > 0 {
+		return ci.MaxParallelToolCalls
+	}
+
+	return defaultMaxParallelToolCalls
+}
+
+// toolCallResult is the outcome of a tool call of the current step.
+type toolCallResult struct {
+	content string                  // Tool message to record for the call
+	failed  bool                    // The call failed and content holds the tool error
+	skipped bool                    // The call was a duplicate and never ran
+	err     error                   // Failure that stops the run, e.g. a canceled context or an unreachable tool server
+	record  *agent.ConversationStep // Audit record of the call, nil for calls that were skipped
+}
+
+// runToolCalls runs the tool calls of a step. Every call is recorded first, then they
+// run concurrently up to the conversation limit and their results are recorded in
+// call order. Calls to sequential tools run on their own, after the calls before
+// them have finished.
+func (rt *Runtime) runToolCalls(ctx context.Context, ci *ConversationInstance, calls []types.ToolCall, history map[toolCallKey]int, step int) error {
+	const op = "runtime.runToolCalls"
+
+	results := make([]toolCallResult, len(calls))
+	pending := make([]int, 0, len(calls))
+
+	// Step 1: Persist the assistant-issued tool calls so resumes have the full transcript.
+	// Every tool result needs its call in the history, even the skipped ones.
+	for i, toolCall := range calls {
+		log.Info().
+			Str("Name", ci.AgentName).
+			Str("ID", ci.ID.String()).
+			Str("tool", toolCall.Name).
+			Str("args", toolCall.Arguments).
+			Int("step", step).
+			Msg("Agent made tool call")
+
+		ci.AddAssistantToolCall(toolCall)
+
+		// 1.1 Check that we are not in an infinite loop of tool calls with identical arguments
+		callKey := toolCallKey{name: toolCall.Name, args: toolCall.Arguments}
+		lastStepCall, found := history[callKey]
+		history[callKey] = step
+
+		if found && step-lastStepCall <= 1 {
+			log.Warn().Str("tool", toolCall.Name).Str("args", toolCall.Arguments).Msg("Skipping tool call due to anti-loop policy")
+
+			// IMPORTANT: Always satisfy the protocol with a ToolMessage for this call_id.
+			// We send a synthetic error payload instead of executing the tool again.
+			results[i] = toolCallResult{content: duplicateToolCallResult, skipped: true}
+			continue
+		}
+
+		// 1.2 Check the arguments against the tool's input schema
+		err := ci.mcpMux.ValidateArguments(&toolCall)
+		if err != nil {
+			ci.InvalidToolCalls++
+
+			log.Warn().Err(err).Str("tool", toolCall.Name).Str("args", toolCall.Arguments).Msg("Invalid tool call")
+
+			record := ci.startToolStep(&toolCall)
+			finishToolStep(record, "", err)
+
+			results[i] = toolCallResult{content: toolErrorResult(toolCall.Name, err), failed: true, record: record}
+			continue
+		}
+
+		pending = append(pending, i)
+	}
+
+	// Step 2: Run the calls batch by batch. Tool failures go back to the model, only a
+	// call that stops the run (an unreachable tool server, a canceled run) skips the
+	// batches after it.
+	for _, batch := range ci.toolCallBatches(calls, pending) {
+		group := errgroup.Group{}
+		group.SetLimit(ci.maxParallelToolCalls())
+
+		for _, i := range batch {
+			group.Go(func() error {
+				results[i] = ci.runToolCall(ctx, &calls[i], step)
+				return results[i].err
+			})
+		}
+
+		if group.Wait() != nil {
+			break
+		}
+	}
+
+	// Step 3: Record the results in call order
+	for _, result := range results {
+		if result.record != nil {
+			rt.saveStep(ctx, result.record)
+		}
+	}
+
+	// Once a call stops the run every call gets a result anyway, so the transcript
+	// stays valid for providers when the conversation is retried or resumed
+	var runErr error
+	for i, toolCall := range calls {
+		if results[i].err != nil && runErr == nil {
+			runErr = results[i].err
+		}
+
+		if runErr != nil {
+			ci.AddToolMessage(toolCall.Name, toolCall.CallID, unfinishedToolResult(ctx, toolCall.Name, results[i]))
+			continue
+		}
+
+		ci.AddToolMessage(toolCall.Name, toolCall.CallID, results[i].content)
+
+		// Skipped calls say nothing about whether the model can use its tools
+		switch {
+		case results[i].skipped:
+		case results[i].failed:
+			ci.toolFailures++
+		default:
+			ci.toolFailures = 0
+		}
+	}
+
+	if runErr != nil {
+		return ez.Wrap(op, runErr)
+	}
+
+	// Step 4: Give up once the model keeps failing to use its tools
+	if ci.MaxConsecutiveToolFailures > 0 && ci.toolFailures > ci.MaxConsecutiveToolFailures {
+		errMsg := fmt.Sprintf("%d consecutive tool calls failed, at most %d are tolerated", ci.toolFailures, ci.MaxConsecutiveToolFailures)
+		return ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	return nil
+}
+
+// unfinishedToolResult is the tool message of a call at or after the one that
+// stopped the run. Calls that finished before the run stopped keep their result.
+func unfinishedToolResult(ctx context.Context, toolName string, result toolCallResult) string {
+	finished := result.err == nil && (result.record != nil || result.content != "")
+
+	switch {
+	case finished:
+		return result.content
+	case errors.Is(context.Cause(ctx), errConversationCanceled):
+		return canceledToolResult
+	case ctx.Err() != nil:
+		return interruptedToolResult
+	case result.err != nil:
+		return toolErrorResult(toolName, result.err)
+	}
+
+	return notRunToolResult
+}
+
+// toolCallBatches splits the calls to run into batches that may run concurrently.
+// A call to a sequential tool is a batch of its own.
+func (ci *ConversationInstance) toolCallBatches(calls []types.ToolCall, pending []int) [][]int {
+	var batches [][]int
+	var batch []int
+
+	for _, i := range pending {
+		if !slices.Contains(ci.SequentialTools, calls[i].Name) {
+			batch = append(batch, i)
+			continue
+		}
+
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+			batch = nil
+		}
+
+		batches = append(batches, []int{i})
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// runToolCall runs a single call with its hooks. It runs alongside the other calls
+// of the step, so it must not change the transcript.
+func (ci *ConversationInstance) runToolCall(ctx context.Context, toolCall *types.ToolCall, step int) toolCallResult {
+	record := ci.startToolStep(toolCall)
+
+	// Step 1: Run any pre-tool-use hooks
+	blocked, err := ci.RunPreToolUseHook(ctx, toolCall, "", record)
+	if err != nil {
+		record.Finish(nil)
+		return toolCallResult{content: blocked, record: record}
+	}
+
+	// Step 2: Call the tool
+	toolCallResponse, err := ci.mcpMux.CallTool(ctx, toolCall)
+	finishToolStep(record, toolCallResponse, err)
+
+	if err != nil {
+		// Stopped runs and infrastructure failures end the run, the model gets the rest
+		toolErr, ok := types.AsToolError(err)
+		if ctx.Err() != nil || !ok || !toolErr.Kind.Recoverable() {
+			return toolCallResult{err: ez.Wrap("agent.ExecuteTool", err), record: record}
+		}
+
+		log.Warn().Err(err).Str("tool", toolCall.Name).Str("args", toolCall.Arguments).Msg("Tool call failed")
+
+		return toolCallResult{content: toolErrorResult(toolCall.Name, err), failed: true, record: record}
+	}
+
+	log.Info().
+		Str("Name", ci.AgentName).
+		Str("ID", ci.ID.String()).
+		Str("tool", toolCall.Name).
+		Str("args", toolCall.Arguments).
+		Str("tool_response", toolCallResponse).
+		Int("step", step).
+		Msg("Tool Call Response")
+
+	// Step 3: Run any post-tool-use hooks
+	blocked, err = ci.RunPostToolUseHook(ctx, toolCall, toolCallResponse, record)
+	if err != nil {
+		return toolCallResult{content: blocked, record: record}
+	}
+
+	return toolCallResult{content: toolCallResponse, record: record}
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/vanclief/compose/primitives/enums"
+	"github.com/vanclief/ez"
+)
+
+// ContentPartType is the kind of content carried by a ContentPart.
+type ContentPartType string
+
+const (
+	ContentPartText  ContentPartType = "text"
+	ContentPartImage ContentPartType = "image"
+	ContentPartFile  ContentPartType = "file"
+)
+
+var contentPartTypeSet = enums.Set([]ContentPartType{
+	ContentPartText,
+	ContentPartImage,
+	ContentPartFile,
+})
+
+func (t ContentPartType) Validate() error {
+	return enums.Validate(t, contentPartTypeSet)
+}
+
+func (t ContentPartType) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(t, contentPartTypeSet)
+}
+
+func (t *ContentPartType) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, t, contentPartTypeSet)
+}
+
+// MaxAttachmentBytes caps the decoded size of an inline image or file.
+const MaxAttachmentBytes = 20 << 20
+
+// Media types every provider accepts inline
+var (
+	imageMediaTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+	fileMediaTypes  = []string{"application/pdf", "text/plain"}
+)
+
+// ContentPart is a piece of a multimodal message. Images and files are passed
+// either by URL or inline as base64 Data with their MediaType.
+type ContentPart struct {
+	Type      ContentPartType
+	Text      string // Text parts only
+	URL       string // Optional: http(s) URL of the image or file
+	Data      string // Optional: base64 encoded image or file
+	MediaType string // MIME type of Data, e.g. image/png or application/pdf
+	Filename  string // Optional: name of the file shown to the model
+}
+
+func (p ContentPart) Validate() error {
+	const op = "ContentPart.Validate"
+
+	err := p.Type.Validate()
+	if err != nil {
+		return ez.Wrap(op, err)
+	}
+
+	if p.Type == ContentPartText {
+		if strings.TrimSpace(p.Text) == "" {
+			return ez.New(op, ez.EINVALID, "text parts require text", nil)
+		}
+		return nil
+	}
+
+	if (p.URL == "") == (p.Data == "") {
+		errMsg := fmt.Sprintf("%s parts require either a url or base64 data", p.Type)
+		return ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	if p.URL != "" {
+		parsed, err := url.Parse(p.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ez.New(op, ez.EINVALID, "url must be an absolute http(s) URL", err)
+		}
+		return nil
+	}
+
+	allowed := imageMediaTypes
+	if p.Type == ContentPartFile {
+		allowed = fileMediaTypes
+	}
+
+	if !slices.Contains(allowed, p.MediaType) {
+		errMsg := fmt.Sprintf("media_type of %s parts must be one of %s", p.Type, strings.Join(allowed, ", "))
+		return ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	if base64.StdEncoding.DecodedLen(len(p.Data)) > MaxAttachmentBytes {
+		errMsg := fmt.Sprintf("%s parts can't be larger than %d MB", p.Type, MaxAttachmentBytes>>20)
+		return ez.New(op, ez.EINVALID, errMsg, nil)
+	}
+
+	_, err = base64.StdEncoding.DecodeString(p.Data)
+	if err != nil {
+		return ez.New(op, ez.EINVALID, "data must be base64 encoded", err)
+	}
+
+	return nil
+}
+
+// DataURL returns the inline data as a data: URL, or the URL of the part.
+func (p ContentPart) DataURL() string {
+	if p.URL != "" {
+		return p.URL
+	}
+
+	return "data:" + p.MediaType + ";base64," + p.Data
+}
+
+// Describe returns a short placeholder for the part in text-only views.
+func (p ContentPart) Describe() string {
+	switch {
+	case p.Type == ContentPartText:
+		return p.Text
+	case p.Filename != "":
+		return fmt.Sprintf("[%s: %s]", p.Type, p.Filename)
+	case p.URL != "":
+		return fmt.Sprintf("[%s: %s]", p.Type, p.URL)
+	default:
+		return fmt.Sprintf("[%s: %s]", p.Type, p.MediaType)
+	}
+}
+
+// estimatedImageTokens is roughly what a full-size image, or a PDF page, costs with
+// either provider.
+const estimatedImageTokens = 1600
+
+// EstimatePartTokens approximates the input tokens of the images and files in the
+// messages, which token counters that only look at text miss.
+func EstimatePartTokens(messages []Message) int {
+	total := 0
+
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			switch {
+			case part.Type == ContentPartText:
+				continue
+			case part.MediaType == "text/plain":
+				// ~4 bytes per token
+				total += base64.StdEncoding.DecodedLen(len(part.Data)) / 4
+			default:
+				total += estimatedImageTokens
+			}
+		}
+	}
+
+	return total
+}
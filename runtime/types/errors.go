@@ -0,0 +1,204 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vanclief/compose/primitives/enums"
+	"github.com/vanclief/ez"
+)
+
+// ErrorClass groups failed provider calls by how the runtime should react to them.
+type ErrorClass string
+
+const (
+	ErrorClassRateLimit     ErrorClass = "rate_limit"
+	ErrorClassOverloaded    ErrorClass = "overloaded"
+	ErrorClassNetwork       ErrorClass = "network"
+	ErrorClassContextLength ErrorClass = "context_length"
+	ErrorClassAuth          ErrorClass = "auth"
+	ErrorClassInvalid       ErrorClass = "invalid_request"
+	ErrorClassCircuitOpen   ErrorClass = "circuit_open"
+	ErrorClassUnknown       ErrorClass = "unknown"
+)
+
+var errorClassSet = enums.Set([]ErrorClass{
+	ErrorClassRateLimit,
+	ErrorClassOverloaded,
+	ErrorClassNetwork,
+	ErrorClassContextLength,
+	ErrorClassAuth,
+	ErrorClassInvalid,
+	ErrorClassCircuitOpen,
+	ErrorClassUnknown,
+})
+
+func (c ErrorClass) Validate() error {
+	return enums.Validate(c, errorClassSet)
+}
+
+func (c ErrorClass) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(c, errorClassSet)
+}
+
+func (c *ErrorClass) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, c, errorClassSet)
+}
+
+// Transient reports whether the same call may succeed if it is tried again later.
+func (c ErrorClass) Transient() bool {
+	switch c {
+	case ErrorClassRateLimit, ErrorClassOverloaded, ErrorClassNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// Code returns the ez code used for errors of this class. Transient errors are
+// unavailable so the job queue retries them once the call retries are spent.
+func (c ErrorClass) Code() string {
+	switch c {
+	case ErrorClassRateLimit, ErrorClassOverloaded, ErrorClassNetwork, ErrorClassCircuitOpen:
+		return ez.EUNAVAILABLE
+	case ErrorClassContextLength, ErrorClassInvalid:
+		return ez.EINVALID
+	case ErrorClassAuth:
+		return ez.ENOTAUTHORIZED
+	default:
+		return ez.EINTERNAL
+	}
+}
+
+// ProviderError is a failed call to an LLM provider API.
+type ProviderError struct {
+	Class      ErrorClass
+	StatusCode int
+	RetryAfter time.Duration // Wait requested by the provider, 0 if none
+	Err        error
+}
+
+// NewProviderError classifies the error of a provider call from its HTTP status,
+// headers and message. Errors that never got a response have a status of 0.
+func NewProviderError(statusCode int, header http.Header, err error) *ProviderError {
+	return &ProviderError{
+		Class:      classify(statusCode, err),
+		StatusCode: statusCode,
+		RetryAfter: retryAfter(header),
+		Err:        err,
+	}
+}
+
+func (e *ProviderError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s (%d): %v", e.Class, e.StatusCode, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %v", e.Class, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// AsProviderError finds the ProviderError behind err, looking through ez wrapping.
+func AsProviderError(err error) (*ProviderError, bool) {
+	for err != nil {
+		var providerErr *ProviderError
+		if errors.As(err, &providerErr) {
+			return providerErr, true
+		}
+
+		ezErr, ok := err.(*ez.Error)
+		if !ok {
+			return nil, false
+		}
+
+		err = ezErr.Err
+	}
+
+	return nil, false
+}
+
+func classify(statusCode int, err error) ErrorClass {
+	message := ""
+	if err != nil {
+		message = strings.ToLower(err.Error())
+	}
+
+	// Providers report an oversized prompt as a bad request
+	for _, marker := range []string{"context_length_exceeded", "maximum context length", "context window", "prompt is too long", "too many tokens"} {
+		if strings.Contains(message, marker) {
+			return ErrorClassContextLength
+		}
+	}
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimit
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusConflict, statusCode >= 500:
+		// Anthropic uses 529 when it is overloaded
+		return ErrorClassOverloaded
+	case statusCode >= 400:
+		return ErrorClassInvalid
+	}
+
+	// No response, e.g. a dropped connection or an error event in a stream
+	switch {
+	case strings.Contains(message, "rate limit"), strings.Contains(message, "rate_limit"):
+		return ErrorClassRateLimit
+	case strings.Contains(message, "overloaded"), strings.Contains(message, "server_error"):
+		return ErrorClassOverloaded
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorClassNetwork
+	}
+
+	return ErrorClassUnknown
+}
+
+// retryAfter reads the wait requested through the retry-after-ms or Retry-After
+// headers, the latter either in seconds or as an HTTP date.
+func retryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+
+	ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64)
+	if err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil {
+		wait := time.Until(date)
+		if wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
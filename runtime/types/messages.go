@@ -1,5 +1,7 @@
 package types
 
+import "strings"
+
 // MessageRole represents the role of a message in the conversation.
 type MessageRole string
 
@@ -16,11 +18,47 @@ const (
 
 // Message is a provider-agnostic representation of a single chat turn or tool result.
 type Message struct {
-	Role       MessageRole // Who sent the message
-	Content    string      // The text or payload of the message
-	Name       string      // Optional: tool name or function name
-	ToolCallID string      // Optional: maps back to the provider's call identifier
-	ToolCall   *ToolCall   // Optional: captures assistant-issued tool calls
+	Role       MessageRole   // Who sent the message
+	Content    string        // The text or payload of the message
+	Name       string        // Optional: tool name or function name
+	ToolCallID string        // Optional: maps back to the provider's call identifier
+	ToolCall   *ToolCall     // Optional: captures assistant-issued tool calls
+	Thinking   *Thinking     // Optional: captures assistant reasoning blocks
+	Parts      []ContentPart `json:",omitempty"` // Optional: text, images and files of a user message, sent instead of Content
+
+	ReasoningSummary *ReasoningSummary `json:",omitempty"` // Optional: summary of the hidden reasoning (OpenAI), never sent back
+	Citations        []Citation        `json:",omitempty"` // Optional: web sources cited by an assistant answer
+}
+
+// IsReasoning reports whether the message only records the reasoning of the
+// assistant rather than an answer or a tool call.
+func (m Message) IsReasoning() bool {
+	return m.Thinking != nil || m.ReasoningSummary != nil
+}
+
+// Thinking is a reasoning block emitted by the model before its answer or tool calls.
+type Thinking struct {
+	Text         string // Reasoning text as returned by the provider
+	Signature    string // Opaque signature that must be echoed back (Anthropic)
+	RedactedData string // Encrypted reasoning returned instead of text when redacted (Anthropic)
+}
+
+// ReasoningSummary is the readable summary a provider returns in place of its
+// hidden reasoning. It is kept for reviewers and not replayed to the model.
+type ReasoningSummary struct {
+	ID   string // Provider identifier of the reasoning item
+	Text string // Summary paragraphs joined by blank lines
+}
+
+// Citation is a web source an answer relies on. StartIndex and EndIndex are the
+// character (rune) offsets of the cited span in the message Content, both 0
+// when unknown.
+type Citation struct {
+	URL        string
+	Title      string
+	CitedText  string `json:",omitempty"` // Optional: quoted source text (Anthropic)
+	StartIndex int
+	EndIndex   int
 }
 
 func NewMessage(role MessageRole, content string) *Message {
@@ -46,6 +84,26 @@ func NewUserMessage(content string) *Message {
 	}
 }
 
+// NewUserMessageWithParts creates a user role message with the prompt followed by
+// its attachments. Content keeps the prompt text for text-only consumers.
+func NewUserMessageWithParts(content string, attachments []ContentPart) *Message {
+	if len(attachments) == 0 {
+		return NewUserMessage(content)
+	}
+
+	parts := make([]ContentPart, 0, len(attachments)+1)
+	if strings.TrimSpace(content) != "" {
+		parts = append(parts, ContentPart{Type: ContentPartText, Text: content})
+	}
+	parts = append(parts, attachments...)
+
+	return &Message{
+		Role:    MessageRoleUser,
+		Content: content,
+		Parts:   parts,
+	}
+}
+
 // NewAssistantMessage creates an assistant role message with given content.
 func NewAssistantMessage(content string) *Message {
 	return &Message{
@@ -74,3 +132,21 @@ func NewAssistantToolCallMessage(toolCall ToolCall) *Message {
 		ToolCall:   &tc,
 	}
 }
+
+// NewAssistantThinkingMessage records a reasoning block emitted by the assistant.
+func NewAssistantThinkingMessage(thinking Thinking) *Message {
+	th := thinking
+	return &Message{
+		Role:     MessageRoleAssistant,
+		Thinking: &th,
+	}
+}
+
+// NewAssistantReasoningSummaryMessage records a reasoning summary emitted by the assistant.
+func NewAssistantReasoningSummaryMessage(summary ReasoningSummary) *Message {
+	rs := summary
+	return &Message{
+		Role:             MessageRoleAssistant,
+		ReasoningSummary: &rs,
+	}
+}
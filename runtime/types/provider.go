@@ -10,16 +10,31 @@ import (
 type LLMProvider interface {
 	Chat(ctx context.Context, model string, request *ChatRequest) (ChatResponse, error)
 	ValidateModel(ctx context.Context, model string) error
-	CalculateCost(model string, inputTokens, outputTokens, cachedTokens int64) int64
+	CalculateCost(model string, usage TokenUsage) float64 // USD cents, unrounded
 	EstimateInputTokens(model string, messages []Message) (int, error)
 	CheckContextWindow(model string, totalInputTokens int, compactionPercentage int) error
 }
 
+// StreamingLLMProvider is implemented by providers that can emit a response while it
+// is being generated. The returned ChatResponse is the same one Chat would return.
+type StreamingLLMProvider interface {
+	LLMProvider
+	ChatStream(ctx context.Context, model string, request *ChatRequest, onEvent StreamHandler) (ChatResponse, error)
+}
+
+// ModelLister is implemented by providers that can list the models available to
+// their credentials.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
 type ChatRequest struct {
 	Messages               []Message
 	Tools                  []ToolDefinition
 	ThinkingEffort         string
 	PreviousResponseID     string // OpenAI specific
+	PreviousMessageCount   int    // Messages already covered by PreviousResponseID
+	Stateless              bool   // Ask the provider not to store the request or response
 	WebSearch              bool
 	StructuredOutputs      bool
 	StructuredOutputSchema map[string]any
@@ -30,13 +45,22 @@ type ChatResponse struct {
 	Text               string
 	Model              string
 	ToolCalls          []ToolCall
+	Thinking           []Thinking
+	ReasoningSummaries []ReasoningSummary
+	Citations          []Citation
 	PreviousResponseID string
 	TokenUsage         TokenUsage
 }
 
+// TokenUsage is what a provider call consumed. InputTokens is the whole prompt,
+// cache reads and writes included.
 type TokenUsage struct {
-	InputTokens           int64 `json:"input_tokens"`
-	OutputTokens          int64 `json:"output_tokens"`
-	CacheReadInputTokens  int64 `json:"cache_read_input_tokens,omitempty"`
-	CacheWriteInputTokens int64 `json:"cache_write_input_tokens,omitempty"`
+	InputTokens           int64            `json:"input_tokens"`
+	OutputTokens          int64            `json:"output_tokens"`
+	CacheReadInputTokens  int64            `json:"cache_read_input_tokens,omitempty"`
+	CacheWriteInputTokens int64            `json:"cache_write_input_tokens,omitempty"`
+	ToolCalls             map[string]int64 `json:"tool_calls,omitempty"` // Billed server-side tool calls by tool
 }
+
+// ToolCallWebSearch counts the web searches run by the provider.
+const ToolCallWebSearch = "web_search"
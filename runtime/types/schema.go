@@ -0,0 +1,142 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/vanclief/ez"
+)
+
+// Schema is a compiled JSON Schema, used for structured outputs and tool arguments.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// schemaURL names the in-memory resource schemas are compiled from
+const schemaURL = "mem://schema.json"
+
+// CompileSchema compiles a JSON Schema given as a decoded JSON object. Schemas
+// without $schema are read as draft 2020-12.
+func CompileSchema(schema map[string]any) (*Schema, error) {
+	const op = "types.CompileSchema"
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, ez.New(op, ez.EINVALID, "schema is not valid JSON", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+
+	err = compiler.AddResource(schemaURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, ez.New(op, ez.EINVALID, "schema is not valid JSON", err)
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		errMsg := fmt.Sprintf("invalid JSON schema: %s", err)
+		return nil, ez.New(op, ez.EINVALID, errMsg, err)
+	}
+
+	return &Schema{schema: compiled}, nil
+}
+
+// SchemaError lists every violation found when validating a value.
+type SchemaError struct {
+	Violations []string // "<JSON pointer>: <message>", the pointer is empty for the root
+}
+
+func (e *SchemaError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// Validate checks a value decoded with DecodeJSON against the schema, returning
+// a *SchemaError when it does not match.
+func (s *Schema) Validate(value any) error {
+	err := s.schema.Validate(value)
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return &SchemaError{Violations: []string{err.Error()}}
+	}
+
+	schemaErr := &SchemaError{}
+	collectViolations(validationErr, schemaErr)
+
+	return schemaErr
+}
+
+// collectViolations flattens the error tree into its leaves, the causes that
+// say what is actually wrong.
+func collectViolations(err *jsonschema.ValidationError, schemaErr *SchemaError) {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		schemaErr.Violations = append(schemaErr.Violations, location+": "+err.Message)
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectViolations(cause, schemaErr)
+	}
+}
+
+// DecodeJSON decodes a single JSON value, keeping numbers as json.Number so
+// large integers survive validation and storage.
+func DecodeJSON(data string) (any, error) {
+	const op = "types.DecodeJSON"
+
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, ez.New(op, ez.EINVALID, "not valid JSON: "+err.Error(), err)
+	}
+
+	// Anything but whitespace after the value is an error
+	_, err = decoder.Token()
+	if err != io.EOF {
+		return nil, ez.New(op, ez.EINVALID, "not valid JSON: unexpected data after the top-level value", nil)
+	}
+
+	return value, nil
+}
+
+var codeFence = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*\\n(.*?)\\n?```$")
+
+// ParseStructuredOutput decodes an assistant answer and validates it against the
+// schema. Models without strict schema support often wrap the JSON in a markdown
+// code fence, which is removed first.
+func ParseStructuredOutput(schema *Schema, text string) (any, error) {
+	const op = "types.ParseStructuredOutput"
+
+	text = strings.TrimSpace(text)
+	if match := codeFence.FindStringSubmatch(text); match != nil {
+		text = strings.TrimSpace(match[1])
+	}
+
+	value, err := DecodeJSON(text)
+	if err != nil {
+		return nil, ez.Wrap(op, err)
+	}
+
+	err = schema.Validate(value)
+	if err != nil {
+		return nil, ez.New(op, ez.EINVALID, "not valid for the schema: "+err.Error(), err)
+	}
+
+	return value, nil
+}
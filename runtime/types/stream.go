@@ -0,0 +1,25 @@
+package types
+
+// StreamEventType identifies the kind of incremental output in a StreamEvent.
+type StreamEventType string
+
+const (
+	// StreamEventTextDelta carries a piece of the assistant's answer.
+	StreamEventTextDelta StreamEventType = "text_delta"
+	// StreamEventReasoningDelta carries a piece of the model's reasoning or reasoning summary.
+	StreamEventReasoningDelta StreamEventType = "reasoning_delta"
+	// StreamEventToolCallDelta carries a piece of a tool call's JSON arguments.
+	StreamEventToolCallDelta StreamEventType = "tool_call_delta"
+)
+
+// StreamEvent is a piece of a response emitted while the provider generates it.
+type StreamEvent struct {
+	Type       StreamEventType
+	Delta      string
+	ToolCallID string // Only set for tool call deltas
+	ToolName   string // Only set for tool call deltas
+}
+
+// StreamHandler receives stream events. It is called synchronously from the
+// goroutine reading the stream, so it must not block.
+type StreamHandler func(event StreamEvent)
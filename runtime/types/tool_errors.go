@@ -0,0 +1,101 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vanclief/compose/primitives/enums"
+	"github.com/vanclief/ez"
+)
+
+// ToolErrorKind groups failed tool calls by how the runtime should react to them.
+// Every kind but infrastructure is sent back to the model so it can correct the call.
+type ToolErrorKind string
+
+const (
+	ToolErrorInvalidArguments ToolErrorKind = "invalid_arguments"
+	ToolErrorUnknownTool      ToolErrorKind = "unknown_tool"
+	ToolErrorFailed           ToolErrorKind = "tool_error"     // The tool ran and reported a failure, e.g. a non-zero exit
+	ToolErrorTimeout          ToolErrorKind = "timeout"        // The tool ran out of time, its partial output is kept
+	ToolErrorInfrastructure   ToolErrorKind = "infrastructure" // The tool server could not be reached
+)
+
+// ToolErrorKindMeta is the _meta field a tool server can set on an error result to
+// report its kind, e.g. timeout. Results without it are tool_error.
+const ToolErrorKindMeta = "error_kind"
+
+var toolErrorKindSet = enums.Set([]ToolErrorKind{
+	ToolErrorInvalidArguments,
+	ToolErrorUnknownTool,
+	ToolErrorFailed,
+	ToolErrorTimeout,
+	ToolErrorInfrastructure,
+})
+
+func (k ToolErrorKind) Validate() error {
+	return enums.Validate(k, toolErrorKindSet)
+}
+
+func (k ToolErrorKind) MarshalJSON() ([]byte, error) {
+	return enums.Marshal(k, toolErrorKindSet)
+}
+
+func (k *ToolErrorKind) UnmarshalJSON(b []byte) error {
+	return enums.Unmarshal(b, k, toolErrorKindSet)
+}
+
+// Recoverable reports whether the model can be told about the failure and carry on.
+func (k ToolErrorKind) Recoverable() bool {
+	return k != ToolErrorInfrastructure
+}
+
+// Code returns the ez code used for errors of this kind. Infrastructure failures
+// are unavailable so the job queue retries the run.
+func (k ToolErrorKind) Code() string {
+	switch k {
+	case ToolErrorInvalidArguments:
+		return ez.EINVALID
+	case ToolErrorUnknownTool:
+		return ez.ENOTFOUND
+	case ToolErrorInfrastructure:
+		return ez.EUNAVAILABLE
+	default:
+		return ez.EINTERNAL
+	}
+}
+
+// ToolError is a failed tool call.
+type ToolError struct {
+	Kind       ToolErrorKind
+	Tool       string
+	Output     string   // What the tool returned along with the failure, if anything
+	Violations []string // Schema violations of the arguments
+	Err        error
+}
+
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Kind, e.Tool, e.Err)
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// AsToolError finds the ToolError behind err, looking through ez wrapping.
+func AsToolError(err error) (*ToolError, bool) {
+	for err != nil {
+		var toolErr *ToolError
+		if errors.As(err, &toolErr) {
+			return toolErr, true
+		}
+
+		ezErr, ok := err.(*ez.Error)
+		if !ok {
+			return nil, false
+		}
+
+		err = ezErr.Err
+	}
+
+	return nil, false
+}
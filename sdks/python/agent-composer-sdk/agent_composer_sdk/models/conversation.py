@@ -38,7 +38,7 @@ class Conversation:
         input_tokens (int):
         output_tokens (int):
         cached_tokens (int):
-        cost (int): Tracked cost in the smallest billing unit for the provider.
+        cost (float): Tracked cost in cents, unrounded.
         created_at (datetime.datetime):
         auto_compact (bool):
         compact_at_percent (int):
@@ -63,7 +63,7 @@ class Conversation:
     input_tokens: int
     output_tokens: int
     cached_tokens: int
-    cost: int
+    cost: float
     created_at: datetime.datetime
     auto_compact: bool
     compact_at_percent: int
@@ -2,8 +2,10 @@ from enum import Enum
 
 
 class ConversationStatus(str, Enum):
+    BUDGET_EXCEEDED = "budget_exceeded"
     CANCELED = "canceled"
     FAILED = "failed"
+    LOOPING = "looping"
     QUEUED = "queued"
     RUNNING = "running"
     SUCCEEDED = "succeeded"
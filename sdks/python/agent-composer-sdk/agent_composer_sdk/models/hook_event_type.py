@@ -2,9 +2,11 @@ from enum import Enum
 
 
 class HookEventType(str, Enum):
+    BUDGET_EXCEEDED = "budget_exceeded"
     CONTEXT_EXCEEDED = "context_exceeded"
     CONVERSATION_ENDED = "conversation_ended"
     CONVERSATION_STARTED = "conversation_started"
+    LOOP_DETECTED = "loop_detected"
     POST_CONTEXT_COMPACTION = "post_context_compaction"
     POST_TOOL_USE = "post_tool_use"
     PRE_CONTEXT_COMPACTION = "pre_context_compaction"
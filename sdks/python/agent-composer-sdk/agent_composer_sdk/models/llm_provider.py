@@ -2,7 +2,9 @@ from enum import Enum
 
 
 class LLMProvider(str, Enum):
+    ANTHROPIC = "anthropic"
     OPEN_AI = "open_ai"
+    OPEN_AI_COMPATIBLE = "open_ai_compatible"
 
     def __str__(self) -> str:
         return str(self.value)